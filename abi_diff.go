@@ -0,0 +1,241 @@
+package web3
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+)
+
+// ABIChange is a difference between two versions of a contract's ABI.
+type ABIChange struct {
+	Type     string `json:"type"` // function, event, error, fallback or receive
+	Sig      string `json:"signature"`
+	Change   string `json:"change"` // added, removed or changed
+	Detail   string `json:"detail,omitempty"`
+	Breaking bool   `json:"breaking"` // whether existing callers or log consumers may break
+}
+
+func (c ABIChange) String() string {
+	class := "additive"
+	if c.Breaking {
+		class = "BREAKING"
+	}
+	s := fmt.Sprintf("%-8s %s %s %s", class, c.Change, c.Type, c.Sig)
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// BreakingChanges reports whether any of changes is breaking.
+func BreakingChanges(changes []ABIChange) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffABI compares the functions and events of an old and new version of a contract's ABI, such as
+// before upgrading a proxy to a new implementation. Removed functions and events, and changes to
+// function outputs, event indexing, or in mutability which callers may rely on, are breaking.
+// Custom errors are compared by DiffErrors.
+func DiffABI(old, new abi.ABI) []ABIChange {
+	var changes []ABIChange
+
+	oldFns, newFns := methodsBySig(old), methodsBySig(new)
+	var removed, added []abi.Method
+	for sig, o := range oldFns {
+		n, ok := newFns[sig]
+		if !ok {
+			removed = append(removed, o)
+			continue
+		}
+		changes = append(changes, diffMethod(o, n)...)
+	}
+	for sig, n := range newFns {
+		if _, ok := oldFns[sig]; !ok {
+			added = append(added, n)
+		}
+	}
+	changes = append(changes, diffSignatures(removed, added)...)
+
+	for _, fn := range []struct {
+		typ      string
+		old, new bool
+	}{
+		{"fallback", old.HasFallback(), new.HasFallback()},
+		{"receive", old.HasReceive(), new.HasReceive()},
+	} {
+		switch {
+		case fn.old && !fn.new:
+			changes = append(changes, ABIChange{Type: fn.typ, Sig: fn.typ + "()", Change: "removed", Breaking: true})
+		case !fn.old && fn.new:
+			changes = append(changes, ABIChange{Type: fn.typ, Sig: fn.typ + "()", Change: "added"})
+		}
+	}
+
+	oldEvents, newEvents := eventsBySig(old), eventsBySig(new)
+	for sig, o := range oldEvents {
+		n, ok := newEvents[sig]
+		if !ok {
+			changes = append(changes, ABIChange{Type: "event", Sig: sig, Change: "removed", Breaking: true})
+			continue
+		}
+		if o.Anonymous != n.Anonymous {
+			changes = append(changes, ABIChange{Type: "event", Sig: sig, Change: "changed", Breaking: true,
+				Detail: fmt.Sprintf("anonymous changed from %t to %t", o.Anonymous, n.Anonymous)})
+		}
+		if oi, ni := indexedInputs(o.Inputs), indexedInputs(n.Inputs); oi != ni {
+			changes = append(changes, ABIChange{Type: "event", Sig: sig, Change: "changed", Breaking: true,
+				Detail: fmt.Sprintf("indexed argument positions changed from (%s) to (%s)", oi, ni)})
+		}
+	}
+	for sig := range newEvents {
+		if _, ok := oldEvents[sig]; !ok {
+			changes = append(changes, ABIChange{Type: "event", Sig: sig, Change: "added"})
+		}
+	}
+	sortChanges(changes)
+	return changes
+}
+
+// DiffErrors compares the custom errors of an old and new version of a contract's ABI.
+// Removed errors are breaking, since callers decoding them would no longer recognize them,
+// while added ones aren't.
+func DiffErrors(old, new []ABIError) []ABIChange {
+	oldSigs, newSigs := map[string]bool{}, map[string]bool{}
+	for _, e := range old {
+		oldSigs[e.Sig()] = true
+	}
+	for _, e := range new {
+		newSigs[e.Sig()] = true
+	}
+	var changes []ABIChange
+	for sig := range oldSigs {
+		if !newSigs[sig] {
+			changes = append(changes, ABIChange{Type: "error", Sig: sig, Change: "removed", Breaking: true})
+		}
+	}
+	for sig := range newSigs {
+		if !oldSigs[sig] {
+			changes = append(changes, ABIChange{Type: "error", Sig: sig, Change: "added"})
+		}
+	}
+	sortChanges(changes)
+	return changes
+}
+
+func methodsBySig(myabi abi.ABI) map[string]abi.Method {
+	m := make(map[string]abi.Method, len(myabi.Methods))
+	for _, method := range myabi.Methods {
+		m[method.Sig] = method
+	}
+	return m
+}
+
+func eventsBySig(myabi abi.ABI) map[string]abi.Event {
+	m := make(map[string]abi.Event, len(myabi.Events))
+	for _, event := range myabi.Events {
+		m[event.Sig] = event
+	}
+	return m
+}
+
+// diffMethod compares two versions of a function with the same signature.
+func diffMethod(o, n abi.Method) []ABIChange {
+	var changes []ABIChange
+	if oo, no := argTypes(o.Outputs), argTypes(n.Outputs); oo != no {
+		changes = append(changes, ABIChange{Type: "function", Sig: o.Sig, Change: "changed", Breaking: true,
+			Detail: fmt.Sprintf("outputs changed from (%s) to (%s)", oo, no)})
+	}
+	om, nm := mutability(o), mutability(n)
+	if om != nm {
+		// Restricting mutability is safe for callers, but view functions becoming writable breaks
+		// static calls, and payable functions becoming nonpayable breaks calls sending value.
+		rank := map[string]int{"pure": 0, "view": 0, "nonpayable": 1, "payable": 2}
+		breaking := (rank[om] == 0 && rank[nm] > 0) || om == "payable"
+		changes = append(changes, ABIChange{Type: "function", Sig: o.Sig, Change: "changed", Breaking: breaking,
+			Detail: fmt.Sprintf("mutability changed from %s to %s", om, nm)})
+	}
+	return changes
+}
+
+// diffSignatures reports removed and added functions, pairing a removed function with an added one of the
+// same name, when each is the only one, as a change to its inputs.
+func diffSignatures(removed, added []abi.Method) []ABIChange {
+	byName := func(ms []abi.Method) map[string][]abi.Method {
+		m := map[string][]abi.Method{}
+		for _, method := range ms {
+			m[method.RawName] = append(m[method.RawName], method)
+		}
+		return m
+	}
+	removedByName, addedByName := byName(removed), byName(added)
+	var changes []ABIChange
+	for name, rs := range removedByName {
+		if as := addedByName[name]; len(rs) == 1 && len(as) == 1 {
+			changes = append(changes, ABIChange{Type: "function", Sig: rs[0].Sig, Change: "changed", Breaking: true,
+				Detail: fmt.Sprintf("inputs changed to %s", as[0].Sig)})
+			delete(addedByName, name)
+			continue
+		}
+		for _, r := range rs {
+			changes = append(changes, ABIChange{Type: "function", Sig: r.Sig, Change: "removed", Breaking: true})
+		}
+	}
+	for _, as := range addedByName {
+		for _, a := range as {
+			changes = append(changes, ABIChange{Type: "function", Sig: a.Sig, Change: "added"})
+		}
+	}
+	return changes
+}
+
+func mutability(m abi.Method) string {
+	switch {
+	case m.StateMutability != "":
+		return m.StateMutability
+	case m.Constant:
+		return "view"
+	case m.Payable:
+		return "payable"
+	}
+	return "nonpayable"
+}
+
+func argTypes(args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return strings.Join(types, ",")
+}
+
+// indexedInputs returns the positions of the indexed args.
+func indexedInputs(args abi.Arguments) string {
+	var indexed []string
+	for i, arg := range args {
+		if arg.Indexed {
+			indexed = append(indexed, fmt.Sprint(i))
+		}
+	}
+	return strings.Join(indexed, ",")
+}
+
+// sortChanges orders changes with breaking ones first, then by type and signature.
+func sortChanges(changes []ABIChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Breaking != b.Breaking {
+			return a.Breaking
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Sig < b.Sig
+	})
+}
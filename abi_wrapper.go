@@ -1,6 +1,7 @@
 package web3
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,26 +13,37 @@ import (
 	"github.com/gochain/web3/assets"
 )
 
-// GetABI accepts either built in contracts (erc20, erc721), a file location or a URL
+// GetABI accepts either built in contracts (erc20, erc721, erc1155, erc4626, erc2612), a file location, a URL, or a
+// human-readable ABI such as "function balanceOf(address owner) view returns (uint256)",
+// with multiple signatures separated by semicolons, or an inline JSON ABI.
+// Files and URLs may also hold human-readable ABIs, with one signature per line.
 func GetABI(abiFile string) (*abi.ABI, error) {
-	abi, err := ABIBuiltIn(abiFile)
-	if err != nil {
-		return nil, fmt.Errorf("Cannot get ABI from the bundled storage: %v", err)
-	}
-	if abi != nil {
-		return abi, nil
+	abi, _, err := GetABIWithErrors(abiFile)
+	return abi, err
+}
+
+// GetABIWithErrors is like GetABI, but also returns the custom errors defined in the ABI,
+// which abi.ABI doesn't support, for DecodeRevertReason, DecodeRevertError and GetRevertReason.
+func GetABIWithErrors(abiFile string) (*abi.ABI, []ABIError, error) {
+	if val, ok := bundledContracts[abiFile]; ok {
+		abi, errs, err := parseABI([]byte(val))
+		if err != nil {
+			return nil, nil, fmt.Errorf("Cannot get ABI from the bundled storage: %v", err)
+		}
+		return abi, errs, nil
 	}
-	abi, err = ABIOpenFile(abiFile)
-	if err == nil {
-		return abi, nil
+	if isHumanABI(abiFile) || strings.HasPrefix(strings.TrimSpace(abiFile), "[") {
+		return parseABI([]byte(abiFile))
 	}
-	// else most likely just not found, log it?
-
-	abi, err = ABIOpenURL(abiFile)
-	if err == nil {
-		return abi, nil
+	b, err := ioutil.ReadFile(abiFile)
+	if err != nil {
+		// else most likely just not found, log it?
+		b, err = fetchABI(abiFile)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	return nil, err
+	return parseABI(b)
 }
 
 func ABIBuiltIn(abiFile string) (*abi.ABI, error) {
@@ -46,11 +58,21 @@ func ABIOpenFile(abiFile string) (*abi.ABI, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer jsonReader.Close()
 	return readAbi(jsonReader)
 }
 
 func ABIOpenURL(abiFile string) (*abi.ABI, error) {
-	resp, err := http.Get(abiFile)
+	b, err := fetchABI(abiFile)
+	if err != nil {
+		return nil, err
+	}
+	abi, _, err := parseABI(b)
+	return abi, err
+}
+
+func fetchABI(url string) ([]byte, error) {
+	resp, err := http.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("error getting ABI: %v", err)
 	}
@@ -62,17 +84,41 @@ func ABIOpenURL(abiFile string) (*abi.ABI, error) {
 		}
 		return nil, fmt.Errorf("error getting ABI %v: %v", resp.StatusCode, string(bodyBytes))
 	}
-	return readAbi(resp.Body)
+	return ioutil.ReadAll(resp.Body)
 }
 
 func readAbi(reader io.Reader) (*abi.ABI, error) {
-	abi, err := abi.JSON(reader)
+	b, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
-	return &abi, nil
+	abi, _, err := parseABI(b)
+	return abi, err
+}
+
+// parseABI parses a JSON or human-readable ABI, returning its custom errors separately.
+func parseABI(b []byte) (*abi.ABI, []ABIError, error) {
+	var err error
+	if isHumanABIJSON(b) {
+		b, err = humanABIToJSON(b)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	b, errs, err := splitABIErrors(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	abi, err := abi.JSON(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &abi, errs, nil
 }
 
 var bundledContracts = map[string]string{
-	"erc20":  assets.ERC20ABI,
-	"erc721": assets.ERC721ABI}
+	"erc20":   assets.ERC20ABI,
+	"erc721":  assets.ERC721ABI,
+	"erc1155": assets.ERC1155ABI,
+	"erc4626": assets.ERC4626ABI,
+	"erc2612": assets.ERC2612ABI}
@@ -0,0 +1,305 @@
+// SPDX-License-Identifier: MIT
+// EIP-1967 proxies, equivalent to OpenZeppelin Contracts v5.0 ERC1967Proxy,
+// TransparentUpgradeableProxy and ProxyAdmin, flattened into one file without imports.
+//
+// The bytecode in erc1967_proxy.go is compiled from this file with solc 0.8.30
+// (soljson-v0.8.30+commit.73712a01), optimizer enabled with 200 runs, EVM version petersburg,
+// and the default ipfs metadata hash. Run `go generate ./assets` to rebuild it. Deployed proxies can
+// be verified with the standard JSON compiler input in ERC1967Proxy.input.json.
+pragma solidity ^0.8.20;
+
+/**
+ * @dev ERC-1967: Proxy Storage Slots. Events emitted by proxies when their slots change.
+ */
+interface IERC1967 {
+    event Upgraded(address indexed implementation);
+
+    event AdminChanged(address previousAdmin, address newAdmin);
+}
+
+/**
+ * @dev Functions of the OpenZeppelin Address library used by the proxies.
+ */
+library Address {
+    error AddressEmptyCode(address target);
+
+    error FailedInnerCall();
+
+    function functionDelegateCall(address target, bytes memory data) internal returns (bytes memory) {
+        (bool success, bytes memory returndata) = target.delegatecall(data);
+        return verifyCallResultFromTarget(target, success, returndata);
+    }
+
+    function verifyCallResultFromTarget(
+        address target,
+        bool success,
+        bytes memory returndata
+    ) internal view returns (bytes memory) {
+        if (!success) {
+            _revert(returndata);
+        } else {
+            if (returndata.length == 0 && target.code.length == 0) {
+                revert AddressEmptyCode(target);
+            }
+            return returndata;
+        }
+    }
+
+    function _revert(bytes memory returndata) private pure {
+        if (returndata.length > 0) {
+            assembly {
+                let returndata_size := mload(returndata)
+                revert(add(32, returndata), returndata_size)
+            }
+        } else {
+            revert FailedInnerCall();
+        }
+    }
+}
+
+/**
+ * @dev Reads and writes the EIP-1967 implementation and admin slots.
+ */
+library ERC1967Utils {
+    // bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+    bytes32 internal constant IMPLEMENTATION_SLOT = 0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc;
+
+    // bytes32(uint256(keccak256("eip1967.proxy.admin")) - 1)
+    bytes32 internal constant ADMIN_SLOT = 0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103;
+
+    event Upgraded(address indexed implementation);
+
+    event AdminChanged(address previousAdmin, address newAdmin);
+
+    error ERC1967InvalidImplementation(address implementation);
+
+    error ERC1967InvalidAdmin(address admin);
+
+    error ERC1967NonPayable();
+
+    function getImplementation() internal view returns (address implementation) {
+        bytes32 slot = IMPLEMENTATION_SLOT;
+        assembly {
+            implementation := sload(slot)
+        }
+    }
+
+    function _setImplementation(address newImplementation) private {
+        if (newImplementation.code.length == 0) {
+            revert ERC1967InvalidImplementation(newImplementation);
+        }
+        bytes32 slot = IMPLEMENTATION_SLOT;
+        assembly {
+            sstore(slot, newImplementation)
+        }
+    }
+
+    /**
+     * @dev Sets the implementation, and delegatecalls data to it if it is not empty.
+     */
+    function upgradeToAndCall(address newImplementation, bytes memory data) internal {
+        _setImplementation(newImplementation);
+        emit Upgraded(newImplementation);
+
+        if (data.length > 0) {
+            Address.functionDelegateCall(newImplementation, data);
+        } else {
+            _checkNonPayable();
+        }
+    }
+
+    function getAdmin() internal view returns (address admin) {
+        bytes32 slot = ADMIN_SLOT;
+        assembly {
+            admin := sload(slot)
+        }
+    }
+
+    function _setAdmin(address newAdmin) private {
+        if (newAdmin == address(0)) {
+            revert ERC1967InvalidAdmin(address(0));
+        }
+        bytes32 slot = ADMIN_SLOT;
+        assembly {
+            sstore(slot, newAdmin)
+        }
+    }
+
+    function changeAdmin(address newAdmin) internal {
+        emit AdminChanged(getAdmin(), newAdmin);
+        _setAdmin(newAdmin);
+    }
+
+    function _checkNonPayable() private {
+        if (msg.value > 0) {
+            revert ERC1967NonPayable();
+        }
+    }
+}
+
+/**
+ * @dev Delegates every call to the implementation returned by _implementation.
+ */
+abstract contract Proxy {
+    function _delegate(address implementation) internal virtual {
+        assembly {
+            calldatacopy(0, 0, calldatasize())
+            let result := delegatecall(gas(), implementation, 0, calldatasize(), 0, 0)
+            returndatacopy(0, 0, returndatasize())
+            switch result
+            case 0 {
+                revert(0, returndatasize())
+            }
+            default {
+                return(0, returndatasize())
+            }
+        }
+    }
+
+    function _implementation() internal view virtual returns (address);
+
+    function _fallback() internal virtual {
+        _delegate(_implementation());
+    }
+
+    fallback() external payable virtual {
+        _fallback();
+    }
+}
+
+/**
+ * @dev An upgradeable proxy whose implementation is kept in the EIP-1967 implementation slot.
+ * Upgrades are left to the implementation, as in UUPS (EIP-1822) contracts.
+ */
+contract ERC1967Proxy is Proxy {
+    /**
+     * @dev Sets the implementation, and delegatecalls _data to it if it is not empty,
+     * typically to call its initializer.
+     */
+    constructor(address implementation, bytes memory _data) payable {
+        ERC1967Utils.upgradeToAndCall(implementation, _data);
+    }
+
+    function _implementation() internal view virtual override returns (address) {
+        return ERC1967Utils.getImplementation();
+    }
+}
+
+abstract contract Context {
+    function _msgSender() internal view virtual returns (address) {
+        return msg.sender;
+    }
+}
+
+abstract contract Ownable is Context {
+    address private _owner;
+
+    error OwnableUnauthorizedAccount(address account);
+
+    error OwnableInvalidOwner(address owner);
+
+    event OwnershipTransferred(address indexed previousOwner, address indexed newOwner);
+
+    constructor(address initialOwner) {
+        if (initialOwner == address(0)) {
+            revert OwnableInvalidOwner(address(0));
+        }
+        _transferOwnership(initialOwner);
+    }
+
+    modifier onlyOwner() {
+        _checkOwner();
+        _;
+    }
+
+    function owner() public view virtual returns (address) {
+        return _owner;
+    }
+
+    function _checkOwner() internal view virtual {
+        if (owner() != _msgSender()) {
+            revert OwnableUnauthorizedAccount(_msgSender());
+        }
+    }
+
+    function renounceOwnership() public virtual onlyOwner {
+        _transferOwnership(address(0));
+    }
+
+    function transferOwnership(address newOwner) public virtual onlyOwner {
+        if (newOwner == address(0)) {
+            revert OwnableInvalidOwner(address(0));
+        }
+        _transferOwnership(newOwner);
+    }
+
+    function _transferOwnership(address newOwner) internal virtual {
+        address oldOwner = _owner;
+        _owner = newOwner;
+        emit OwnershipTransferred(oldOwner, newOwner);
+    }
+}
+
+/**
+ * @dev The only function of a TransparentUpgradeableProxy, callable only by its ProxyAdmin.
+ */
+interface ITransparentUpgradeableProxy is IERC1967 {
+    function upgradeToAndCall(address, bytes calldata) external payable;
+}
+
+/**
+ * @dev The admin of a TransparentUpgradeableProxy, which upgrades it on behalf of its owner.
+ */
+contract ProxyAdmin is Ownable {
+    string public constant UPGRADE_INTERFACE_VERSION = "5.0.0";
+
+    constructor(address initialOwner) Ownable(initialOwner) {}
+
+    /**
+     * @dev Upgrades proxy to implementation, and delegatecalls data to it if it is not empty.
+     */
+    function upgradeAndCall(
+        ITransparentUpgradeableProxy proxy,
+        address implementation,
+        bytes memory data
+    ) public payable virtual onlyOwner {
+        proxy.upgradeToAndCall{value: msg.value}(implementation, data);
+    }
+}
+
+/**
+ * @dev A transparent proxy, administered by a ProxyAdmin it creates for initialOwner.
+ * Calls from the ProxyAdmin may only upgrade the proxy, and all other calls are delegated
+ * to the implementation, so the implementation's functions can't clash with the proxy's.
+ */
+contract TransparentUpgradeableProxy is ERC1967Proxy {
+    address private immutable _admin;
+
+    error ProxyDeniedAdminAccess();
+
+    constructor(address _logic, address initialOwner, bytes memory _data) payable ERC1967Proxy(_logic, _data) {
+        _admin = address(new ProxyAdmin(initialOwner));
+        ERC1967Utils.changeAdmin(_proxyAdmin());
+    }
+
+    function _proxyAdmin() internal virtual returns (address) {
+        return _admin;
+    }
+
+    function _fallback() internal virtual override {
+        if (msg.sender == _proxyAdmin()) {
+            if (msg.sig != ITransparentUpgradeableProxy.upgradeToAndCall.selector) {
+                revert ProxyDeniedAdminAccess();
+            } else {
+                _dispatchUpgradeToAndCall();
+            }
+        } else {
+            super._fallback();
+        }
+    }
+
+    function _dispatchUpgradeToAndCall() private {
+        (address newImplementation, bytes memory data) = abi.decode(msg.data[4:], (address, bytes));
+        ERC1967Utils.upgradeToAndCall(newImplementation, data);
+    }
+}
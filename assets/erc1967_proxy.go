@@ -0,0 +1,39 @@
+package assets
+
+//go:generate go run gen_erc1967_proxy.go
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"github.com/gochain/gochain/v4/common"
+)
+
+// TransparentProxyCode returns the deployment code for a transparent proxy of logic, administered
+// by a ProxyAdmin owned by initialOwner, which delegatecalls data to logic when it is non-empty.
+func TransparentProxyCode(logic, initialOwner common.Address, data []byte) string {
+	return TransparentProxyBin + encodeProxyArgs(data, logic, initialOwner)
+}
+
+// ERC1967ProxyCode returns the deployment code for an EIP-1967 proxy of logic,
+// which delegatecalls data to logic when it is non-empty.
+func ERC1967ProxyCode(logic common.Address, data []byte) string {
+	return ERC1967ProxyBin + encodeProxyArgs(data, logic)
+}
+
+// encodeProxyArgs ABI encodes the constructor arguments (addrs..., bytes data).
+func encodeProxyArgs(data []byte, addrs ...common.Address) string {
+	var sb strings.Builder
+	for _, addr := range addrs {
+		sb.WriteString(hex.EncodeToString(common.LeftPadBytes(addr.Bytes(), 32)))
+	}
+	offset := big.NewInt(int64(32 * (len(addrs) + 1)))
+	sb.WriteString(hex.EncodeToString(common.LeftPadBytes(offset.Bytes(), 32)))
+	size := big.NewInt(int64(len(data)))
+	sb.WriteString(hex.EncodeToString(common.LeftPadBytes(size.Bytes(), 32)))
+	if len(data) > 0 {
+		sb.WriteString(hex.EncodeToString(common.RightPadBytes(data, (len(data)+31)/32*32)))
+	}
+	return sb.String()
+}
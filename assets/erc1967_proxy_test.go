@@ -0,0 +1,73 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestERC1967ProxyBin checks that the committed compiler input holds ERC1967Proxy.sol, and, when solc
+// 0.8.30 is available as $SOLC or solc, that compiling it reproduces the committed bytecode.
+func TestERC1967ProxyBin(t *testing.T) {
+	inputJSON, err := os.ReadFile("ERC1967Proxy.input.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input struct {
+		Sources map[string]struct {
+			Content string `json:"content"`
+		} `json:"sources"`
+	}
+	if err := json.Unmarshal(inputJSON, &input); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile("ERC1967Proxy.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Sources["ERC1967Proxy.sol"].Content != string(src) {
+		t.Fatal("ERC1967Proxy.input.json is out of date with ERC1967Proxy.sol; run go generate")
+	}
+
+	solc := []string{"solc"}
+	if s := os.Getenv("SOLC"); s != "" {
+		solc = strings.Fields(s)
+	}
+	version, err := exec.Command(solc[0], append(solc[1:], "--version")...).Output()
+	if err != nil {
+		t.Skipf("solc is not available: %v", err)
+	}
+	if !strings.Contains(string(version), "Version: 0.8.30+") {
+		t.Skipf("solc 0.8.30 is required but got:\n%s", version)
+	}
+	cmd := exec.Command(solc[0], append(solc[1:], "--standard-json")...)
+	cmd.Stdin = bytes.NewReader(inputJSON)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("solc failed: %v", err)
+	}
+	var output struct {
+		Contracts map[string]map[string]struct {
+			EVM struct {
+				Bytecode struct {
+					Object string `json:"object"`
+				} `json:"bytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(out, &output); err != nil {
+		t.Fatalf("Invalid solc output: %v", err)
+	}
+	for name, bin := range map[string]string{
+		"ERC1967Proxy":                ERC1967ProxyBin,
+		"TransparentUpgradeableProxy": TransparentProxyBin,
+		"ProxyAdmin":                  ProxyAdminBin,
+	} {
+		if got := "0x" + output.Contracts["ERC1967Proxy.sol"][name].EVM.Bytecode.Object; got != bin {
+			t.Errorf("compiled %s doesn't match the committed bytecode; run go generate", name)
+		}
+	}
+}
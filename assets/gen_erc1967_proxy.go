@@ -0,0 +1,126 @@
+//go:build ignore
+
+// gen_erc1967_proxy compiles ERC1967Proxy.sol and writes the bytecode and ABIs of its proxies to
+// erc1967_proxy_bin.go, and the standard JSON compiler input, for verifying deployed proxies, to
+// ERC1967Proxy.input.json.
+//
+// It runs solc 0.8.30 with docker, or the solc command in the SOLC environment variable, which
+// must be version 0.8.30 to reproduce the committed bytecode. Run it with make proxy-assets.
+//
+// The EVM target is petersburg because the GoChain EVM implements no later instruction set: code
+// compiled for a later target may use opcodes it lacks, like CHAINID or PUSH0, and fail to run.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	source      = "ERC1967Proxy.sol"
+	solcVersion = "0.8.30"
+)
+
+// contracts are the compiled contracts, and the names of their constants.
+var contracts = []struct{ name, constant, doc string }{
+	{"ERC1967Proxy", "ERC1967Proxy", `ERC1967ProxyBin is an EIP-1967 proxy with the constructor (address implementation, bytes data),
+// for use with UUPS (EIP-1822) implementations which carry their own upgrade logic.
+// Every call is delegated to the implementation.`},
+	{"TransparentUpgradeableProxy", "TransparentProxy", `TransparentProxyBin is an EIP-1967 transparent proxy with the constructor
+// (address logic, address initialOwner, bytes data). It creates a ProxyAdmin owned by initialOwner,
+// whose address it keeps in the EIP-1967 admin slot, and which is the only account allowed to
+// upgrade it. All other calls are delegated to the implementation.`},
+	{"ProxyAdmin", "ProxyAdmin", `ProxyAdminBin is the admin contract created by TransparentProxyBin.
+// Its owner upgrades the proxy with upgradeAndCall.`},
+}
+
+func main() {
+	src, err := os.ReadFile(source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	selection := map[string][]string{}
+	for _, c := range contracts {
+		selection[c.name] = []string{"abi", "evm.bytecode.object"}
+	}
+	input := map[string]interface{}{
+		"language": "Solidity",
+		"sources":  map[string]interface{}{source: map[string]string{"content": string(src)}},
+		"settings": map[string]interface{}{
+			"optimizer":       map[string]interface{}{"enabled": true, "runs": 200},
+			"evmVersion":      "petersburg", // The latest target the GoChain EVM runs.
+			"outputSelection": map[string]interface{}{source: selection},
+		},
+	}
+	inputJSON, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	solc := []string{"docker", "run", "-i", "--rm", "ethereum/solc:" + solcVersion}
+	if s := os.Getenv("SOLC"); s != "" {
+		solc = strings.Fields(s)
+	}
+	version, err := exec.Command(solc[0], append(solc[1:], "--version")...).Output()
+	if err != nil {
+		log.Fatalf("Cannot run %s: %v", strings.Join(solc, " "), err)
+	}
+	if !strings.Contains(string(version), "Version: "+solcVersion+"+") {
+		log.Fatalf("Expected solc %s but got:\n%s", solcVersion, version)
+	}
+	cmd := exec.Command(solc[0], append(solc[1:], "--standard-json")...)
+	cmd.Stdin = bytes.NewReader(inputJSON)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		log.Fatalf("solc failed: %v", err)
+	}
+	var output struct {
+		Errors []struct {
+			Severity         string `json:"severity"`
+			FormattedMessage string `json:"formattedMessage"`
+		} `json:"errors"`
+		Contracts map[string]map[string]struct {
+			ABI json.RawMessage `json:"abi"`
+			EVM struct {
+				Bytecode struct {
+					Object string `json:"object"`
+				} `json:"bytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(out, &output); err != nil {
+		log.Fatalf("Invalid solc output: %v", err)
+	}
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			log.Fatal(e.FormattedMessage)
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen_erc1967_proxy.go from %s with solc %s. DO NOT EDIT.\n\npackage assets\n", source, solcVersion)
+	for _, c := range contracts {
+		compiled, ok := output.Contracts[source][c.name]
+		if !ok || compiled.EVM.Bytecode.Object == "" {
+			log.Fatalf("Missing %s in solc output", c.name)
+		}
+		var abi bytes.Buffer
+		if err := json.Indent(&abi, compiled.ABI, "", "\t"); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(&b, "\n// %s\nconst %sBin = `0x%s`\n", c.doc, c.constant, compiled.EVM.Bytecode.Object)
+		fmt.Fprintf(&b, "\n// %sABI is the ABI of %sBin.\nconst %sABI = `%s`\n", c.constant, c.constant, c.constant, abi.String())
+	}
+	if err := os.WriteFile("erc1967_proxy_bin.go", b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("ERC1967Proxy.input.json", append(inputJSON, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package assets
+
+// CommonSignatures are the text signatures of widely used standard functions and events,
+// one per line, prefixed with "function" or "event". They seed the signature database
+// along with the bundled ABIs.
+const CommonSignatures = `
+# ERC-20 extensions and wrapped native tokens
+function increaseAllowance(address,uint256)
+function decreaseAllowance(address,uint256)
+function mint(address,uint256)
+function burn(uint256)
+function burnFrom(address,uint256)
+function deposit()
+function withdraw(uint256)
+event Deposit(address,uint256)
+event Withdrawal(address,uint256)
+
+# ERC-2612 permit
+function permit(address,address,uint256,uint256,uint8,bytes32,bytes32)
+function nonces(address)
+function DOMAIN_SEPARATOR()
+
+# ERC-721 extensions
+function safeMint(address,uint256)
+function tokenOfOwnerByIndex(address,uint256)
+function tokenByIndex(uint256)
+
+# ERC-1155
+function balanceOfBatch(address[],uint256[])
+function safeTransferFrom(address,address,uint256,uint256,bytes)
+function safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)
+function uri(uint256)
+event TransferSingle(address,address,address,uint256,uint256)
+event TransferBatch(address,address,address,uint256[],uint256[])
+event URI(string,uint256)
+
+# ERC-4626
+function asset()
+function totalAssets()
+function convertToShares(uint256)
+function convertToAssets(uint256)
+function deposit(uint256,address)
+function mint(uint256,address)
+function withdraw(uint256,address,address)
+function redeem(uint256,address,address)
+event Deposit(address,address,uint256,uint256)
+event Withdraw(address,address,address,uint256,uint256)
+
+# ERC-165
+function supportsInterface(bytes4)
+
+# Ownable and AccessControl
+function owner()
+function transferOwnership(address)
+function renounceOwnership()
+function hasRole(bytes32,address)
+function grantRole(bytes32,address)
+function revokeRole(bytes32,address)
+function renounceRole(bytes32,address)
+event OwnershipTransferred(address,address)
+event RoleGranted(bytes32,address,address)
+event RoleRevoked(bytes32,address,address)
+
+# Pausable
+function pause()
+function unpause()
+event Paused(address)
+event Unpaused(address)
+
+# ERC-1967 proxies
+function upgradeTo(address)
+function upgradeToAndCall(address,bytes)
+function implementation()
+function admin()
+function changeAdmin(address)
+event Upgraded(address)
+event AdminChanged(address,address)
+event BeaconUpgraded(address)
+
+# Multicall
+function multicall(bytes[])
+function aggregate((address,bytes)[])
+`
@@ -0,0 +1,154 @@
+package web3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/crypto"
+)
+
+// CallData is decoded transaction input.
+type CallData struct {
+	Method string    `json:"method"` // "constructor" for contract creations
+	Sig    string    `json:"signature"`
+	Args   []CallArg `json:"args"`
+}
+
+// CallArg is a decoded argument of a call.
+type CallArg struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// String formats the call like `transfer(address to: 0x..., uint256 amount: 1)`.
+func (c *CallData) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		value := arg.Value
+		// Addresses and hashes format as raw bytes with %v.
+		if s, ok := value.(fmt.Stringer); ok {
+			value = s.String()
+		}
+		if arg.Name == "" {
+			args[i] = fmt.Sprintf("%s: %v", arg.Type, value)
+		} else {
+			args[i] = fmt.Sprintf("%s %s: %v", arg.Type, arg.Name, value)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", c.Method, strings.Join(args, ", "))
+}
+
+// DecodeCallData decodes the input of a contract function call, identifying the method by its selector.
+func DecodeCallData(myabi abi.ABI, input []byte) (*CallData, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("input too short (%d bytes) for a function call", len(input))
+	}
+	m, err := myabi.MethodById(input[:4])
+	if err != nil {
+		return nil, err
+	}
+	vals, err := m.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s arguments: %v", m.Sig, err)
+	}
+	return &CallData{Method: m.RawName, Sig: m.Sig, Args: callArgs(m.Inputs, vals)}, nil
+}
+
+// DecodeConstructorArgs decodes the constructor arguments of a contract creation, which are
+// appended to the contract bytecode in the input. Since the length of the bytecode is unknown,
+// the arguments are taken to be the shortest suffix of the input which is a valid encoding.
+func DecodeConstructorArgs(myabi abi.ABI, input []byte) (*CallData, error) {
+	args := myabi.Constructor.Inputs
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	cd := &CallData{Method: "constructor", Sig: fmt.Sprintf("constructor(%s)", strings.Join(types, ","))}
+	if len(args) == 0 {
+		return cd, nil
+	}
+	for n := 32 * len(args); n <= len(input); n += 32 {
+		suffix := input[len(input)-n:]
+		vals, err := args.UnpackValues(suffix)
+		if err != nil {
+			continue
+		}
+		packed, err := args.Pack(vals...)
+		if err != nil || !bytes.Equal(packed, suffix) {
+			continue
+		}
+		cd.Args = callArgs(args, vals)
+		return cd, nil
+	}
+	return nil, errors.New("no valid constructor arguments found in input")
+}
+
+// EncodeCallData encodes a call of the function with the text signature sig, such as
+// "transfer(address to, uint256 amount)" or "function transfer(address to, uint256 amount)",
+// converting params as ConvertArguments does.
+// A signature without a name, such as "(address,uint256)", encodes only the arguments, without
+// a selector, as for constructor arguments.
+func EncodeCallData(sig string, params ...interface{}) ([]byte, error) {
+	var args abi.Arguments
+	var selector []byte
+	if s := strings.TrimSpace(sig); strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		var err error
+		args, err = ParseArguments(s[1 : len(s)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature %q: %v", sig, err)
+		}
+	} else {
+		name, fnArgs, err := parseSignature(sig)
+		if err != nil {
+			return nil, err
+		}
+		args = fnArgs
+		selector = crypto.Keccak256([]byte(signature(name, args)))[:4]
+	}
+	goParams, err := ConvertArguments(args, params)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := args.Pack(goParams...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack values: %v", err)
+	}
+	return append(selector, packed...), nil
+}
+
+// CanonicalSignature returns the canonical form of a function, event or error signature, from
+// which selectors and topics are derived, e.g. "transfer(address,uint256)" for
+// "function transfer(address to, uint256 amount) external returns (bool)".
+func CanonicalSignature(sig string) (string, error) {
+	name, args, err := parseSignature(sig)
+	if err != nil {
+		return "", err
+	}
+	return signature(name, args), nil
+}
+
+// DecodeArguments decodes data as the values of args, such as the return data of a function.
+func DecodeArguments(args abi.Arguments, data []byte) ([]CallArg, error) {
+	vals, err := args.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	return callArgs(args, vals), nil
+}
+
+func callArgs(args abi.Arguments, vals []interface{}) []CallArg {
+	vals = convertOutputParams(vals)
+	callArgs := make([]CallArg, len(vals))
+	for i, v := range vals {
+		if b, ok := v.([]byte); ok {
+			v = hexutil.Bytes(b)
+		}
+		callArgs[i] = CallArg{Name: args[i].Name, Type: args[i].Type.String(), Value: v}
+	}
+	return callArgs
+}
@@ -3,9 +3,11 @@ package web3
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
 	"sync/atomic"
 
 	"github.com/gochain/gochain/v4/common"
@@ -20,6 +22,8 @@ type Client interface {
 	GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error)
 	// GetCode returns the code for an address at the given block number (nil for latest).
 	GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error)
+	// GetStorageAt returns the value of a storage slot of an address at the given block number (nil for latest).
+	GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error)
 	// GetBlockByNumber returns block details by number (nil for latest), optionally including full txs.
 	GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error)
 	// GetBlockByHash returns block details for the given hash, optionally include full transaction details.
@@ -38,13 +42,37 @@ type Client interface {
 	GetNetworkID(ctx context.Context) (*big.Int, error)
 	// GetGasPrice returns a suggested gas price.
 	GetGasPrice(ctx context.Context) (*big.Int, error)
+	// GetMaxPriorityFeePerGas returns a suggested priority fee (tip) for dynamic fee transactions.
+	GetMaxPriorityFeePerGas(ctx context.Context) (*big.Int, error)
+	// GetFeeHistory returns base fees, gas used ratios and the given percentiles of priority
+	// fees for blockCount blocks up to and including lastBlock (nil for latest).
+	GetFeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*FeeHistory, error)
 	// GetPendingTransactionCount returns the transaction count including pending txs.
 	// This value is also the next legal nonce.
 	GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error)
 	// SendRawTransaction sends the signed raw transaction bytes.
 	SendRawTransaction(ctx context.Context, tx []byte) error
 	// Call executes a call without submitting a transaction.
+	// If the call reverts, the error is a *RevertError.
 	Call(ctx context.Context, msg CallMsg) ([]byte, error)
+	// CallAt executes a call against the state of the given block, with optional
+	// state overrides (nil for none), without submitting a transaction.
+	CallAt(ctx context.Context, msg CallMsg, block BlockNumberOrHash, overrides StateOverride) ([]byte, error)
+	// EstimateGas returns an estimate of the gas required to execute msg as a transaction.
+	// If execution reverts, the error is a *RevertError.
+	EstimateGas(ctx context.Context, msg CallMsg) (uint64, error)
+	// GetLogs returns the logs matching the filter query. Block ranges rejected by the
+	// node as too wide are split and queried in smaller pieces.
+	GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error)
+	// SubscribeNewHeads delivers the header of each new block to ch. The subscription lasts
+	// until ctx is done or it is unsubscribed. Requires a websocket connection.
+	SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error)
+	// SubscribeLogs delivers new logs matching the filter query's addresses and topics to ch.
+	// The subscription lasts until ctx is done or it is unsubscribed. Requires a websocket connection.
+	SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- types.Log) (Subscription, error)
+	// SubscribePendingTransactions delivers the hash of each new pending transaction to ch.
+	// The subscription lasts until ctx is done or it is unsubscribed. Requires a websocket connection.
+	SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error)
 	Close()
 	SetChainID(*big.Int)
 }
@@ -73,14 +101,31 @@ func (c *client) Close() {
 }
 
 func (c *client) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
+	return c.CallAt(ctx, msg, BlockNumberOrHash{}, nil)
+}
+
+func (c *client) CallAt(ctx context.Context, msg CallMsg, block BlockNumberOrHash, overrides StateOverride) ([]byte, error) {
+	args := []interface{}{toCallArg(msg), toBlockNumOrHashArg(block)}
+	if len(overrides) > 0 {
+		args = append(args, overrides)
+	}
 	var result hexutil.Bytes
-	err := c.r.CallContext(ctx, &result, "eth_call", toCallArg(msg), "latest")
+	err := c.r.CallContext(ctx, &result, "eth_call", args...)
 	if err != nil {
-		return nil, err
+		return nil, toRevertError(err)
 	}
 	return result, err
 }
 
+func (c *client) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.r.CallContext(ctx, &result, "eth_estimateGas", toCallArg(msg))
+	if err != nil {
+		return 0, toRevertError(err)
+	}
+	return uint64(result), nil
+}
+
 func (c *client) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
 	var result hexutil.Big
 	err := c.r.CallContext(ctx, &result, "eth_getBalance", common.HexToAddress(address), toBlockNumArg(blockNumber))
@@ -93,6 +138,15 @@ func (c *client) GetCode(ctx context.Context, address string, blockNumber *big.I
 	return result, err
 }
 
+func (c *client) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	var result hexutil.Bytes
+	err := c.r.CallContext(ctx, &result, "eth_getStorageAt", address, slot, toBlockNumArg(blockNumber))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(result), nil
+}
+
 func (c *client) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
 	return c.getBlock(ctx, "eth_getBlockByNumber", toBlockNumArg(number), includeTxs)
 }
@@ -195,6 +249,26 @@ func (c *client) GetGasPrice(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+func (c *client) GetMaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
+	var hex hexutil.Big
+	if err := c.r.CallContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&hex), nil
+}
+
+func (c *client) GetFeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*FeeHistory, error) {
+	if rewardPercentiles == nil {
+		rewardPercentiles = []float64{}
+	}
+	var h FeeHistory
+	err := c.r.CallContext(ctx, &h, "eth_feeHistory", hexutil.Uint64(blockCount), toBlockNumArg(lastBlock), rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
 func (c *client) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
 	return c.getTransactionCount(ctx, account, "pending")
 }
@@ -209,6 +283,118 @@ func (c *client) SendRawTransaction(ctx context.Context, tx []byte) error {
 	return c.r.CallContext(ctx, nil, "eth_sendRawTransaction", common.ToHex(tx))
 }
 
+func (c *client) GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	logs, err := c.getLogs(ctx, q)
+	if err == nil || q.BlockHash != nil || !isLogRangeErr(err) {
+		return logs, err
+	}
+	// The node rejected the range, so resolve the bounds, which default to the latest block, and split it up.
+	from, to := q.FromBlock, q.ToBlock
+	if from == nil || to == nil {
+		latest, err := c.blockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get latest block number: %v", err)
+		}
+		if from == nil {
+			from = latest
+		}
+		if to == nil {
+			to = latest
+		}
+	}
+	return c.getLogsRange(ctx, q, from, to)
+}
+
+// getLogsRange queries logs between from and to (inclusive), recursively halving the range
+// for as long as the node rejects it as too wide.
+func (c *client) getLogsRange(ctx context.Context, q FilterQuery, from, to *big.Int) ([]*types.Log, error) {
+	q.FromBlock, q.ToBlock = from, to
+	logs, err := c.getLogs(ctx, q)
+	if err == nil || !isLogRangeErr(err) || from.Cmp(to) >= 0 {
+		return logs, err
+	}
+	mid := new(big.Int).Add(from, to)
+	mid.Rsh(mid, 1)
+	logs, err = c.getLogsRange(ctx, q, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	more, err := c.getLogsRange(ctx, q, new(big.Int).Add(mid, big.NewInt(1)), to)
+	if err != nil {
+		return nil, err
+	}
+	return append(logs, more...), nil
+}
+
+func (c *client) getLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []*types.Log
+	err = c.r.CallContext(ctx, &result, "eth_getLogs", arg)
+	return result, err
+}
+
+func (c *client) blockNumber(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	err := c.r.CallContext(ctx, &result, "eth_blockNumber")
+	return (*big.Int)(&result), err
+}
+
+// logRangeErrs are fragments of the error messages nodes and providers return when an
+// eth_getLogs range is too wide or matches too many results. Error codes, such as -32005, and
+// generic messages like "limit exceeded" aren't used, since providers return them for rate
+// limiting too, when splitting the range would only send more requests.
+var logRangeErrs = []string{
+	"query returned more than",
+	"block range is too large",
+	"block range too large",
+	"block range exceeds",
+	"exceed maximum block range",
+	"range too large",
+	"range is too large",
+	"too many blocks",
+	"response size exceeded",
+	"response size should not greater than",
+	"log response size exceeded",
+}
+
+// isLogRangeErr returns true if err indicates that an eth_getLogs query should be retried
+// with a narrower block range.
+func isLogRangeErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range logRangeErrs {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *client) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error) {
+	return newResubscription(ctx, func(ctx context.Context) (*rpc.ClientSubscription, error) {
+		return c.r.EthSubscribe(ctx, ch, "newHeads")
+	})
+}
+
+func (c *client) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- types.Log) (Subscription, error) {
+	// Only new logs are delivered, so the block range doesn't apply.
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	return newResubscription(ctx, func(ctx context.Context) (*rpc.ClientSubscription, error) {
+		return c.r.EthSubscribe(ctx, ch, "logs", arg)
+	})
+}
+
+func (c *client) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error) {
+	return newResubscription(ctx, func(ctx context.Context) (*rpc.ClientSubscription, error) {
+		return c.r.EthSubscribe(ctx, ch, "newPendingTransactions")
+	})
+}
+
 func (c *client) getBlock(ctx context.Context, method string, hashOrNum string, includeTxs bool) (*Block, error) {
 	var raw json.RawMessage
 	err := c.r.CallContext(ctx, &raw, method, hashOrNum, includeTxs)
@@ -268,6 +454,33 @@ func toBlockNumArg(number *big.Int) string {
 	return hexutil.EncodeBig(number)
 }
 
+// toBlockNumOrHashArg returns a block number, or an EIP-1898 block hash object.
+func toBlockNumOrHashArg(block BlockNumberOrHash) interface{} {
+	if block.Hash != nil {
+		return map[string]interface{}{"blockHash": *block.Hash}
+	}
+	return toBlockNumArg(block.Number)
+}
+
+func toFilterArg(q FilterQuery) (interface{}, error) {
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	if q.BlockHash != nil {
+		arg["blockHash"] = *q.BlockHash
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return nil, errors.New("cannot specify both BlockHash and FromBlock/ToBlock")
+		}
+	} else {
+		if q.FromBlock != nil {
+			arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+		}
+		arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	}
+	return arg, nil
+}
+
 func toCallArg(msg CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"to": msg.To,
@@ -1,10 +1,17 @@
 package web3
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/core/types"
+	"github.com/gochain/gochain/v4/rpc"
 )
 
 func TestRPCClient_GetBlockByNumber(t *testing.T) {
@@ -90,3 +97,282 @@ func testAddr(network string) string {
 		panic("unsupported network: " + network)
 	}
 }
+
+// logsService is an in-process eth service which rejects eth_getLogs ranges wider than maxRange,
+// and every query when rateLimited.
+type logsService struct {
+	maxRange    uint64
+	latest      uint64
+	rateLimited bool
+	calls       int
+}
+
+// rateLimitError is the error of a provider's rate limit.
+type rateLimitError struct{}
+
+func (e *rateLimitError) Error() string  { return "daily request count exceeded, request rate limited" }
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (s *logsService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.latest)
+}
+
+func (s *logsService) GetLogs(arg map[string]interface{}) ([]*types.Log, error) {
+	s.calls++
+	if s.rateLimited {
+		return nil, &rateLimitError{}
+	}
+	// Nodes default to the latest block.
+	from, to := s.latest, s.latest
+	var err error
+	if arg["fromBlock"] != nil {
+		from, err = hexutil.DecodeUint64(arg["fromBlock"].(string))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if arg["toBlock"] != "latest" {
+		to, err = hexutil.DecodeUint64(arg["toBlock"].(string))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if to-from+1 > s.maxRange {
+		return nil, fmt.Errorf("query returned more than %d results", s.maxRange)
+	}
+	var logs []*types.Log
+	for n := from; n <= to; n++ {
+		logs = append(logs, &types.Log{BlockNumber: n, Topics: []common.Hash{}})
+	}
+	return logs, nil
+}
+
+func TestClient_GetLogs(t *testing.T) {
+	svc := &logsService{maxRange: 10, latest: 99}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+
+	logs, err := c.GetLogs(context.Background(), FilterQuery{FromBlock: new(big.Int)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logs) != 100 {
+		t.Fatalf("expected 100 logs but got %d", len(logs))
+	}
+	for i, l := range logs {
+		if l.BlockNumber != uint64(i) {
+			t.Fatalf("expected log %d from block %d but got %d", i, i, l.BlockNumber)
+		}
+	}
+
+	svc.calls = 0
+	logs, err = c.GetLogs(context.Background(), FilterQuery{FromBlock: big.NewInt(5), ToBlock: big.NewInt(9)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logs) != 5 || svc.calls != 1 {
+		t.Errorf("expected 5 logs from a single call but got %d from %d calls", len(logs), svc.calls)
+	}
+
+	// Without a first block, only the latest block is queried.
+	svc.calls = 0
+	logs, err = c.GetLogs(context.Background(), FilterQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logs) != 1 || logs[0].BlockNumber != 99 || svc.calls != 1 {
+		t.Errorf("expected 1 log from the latest block from a single call but got %d from %d calls", len(logs), svc.calls)
+	}
+
+	// Rate limit errors are returned as they are, instead of splitting the range.
+	svc.calls, svc.rateLimited = 0, true
+	_, err = c.GetLogs(context.Background(), FilterQuery{FromBlock: new(big.Int)})
+	if e, ok := err.(rpc.Error); !ok || e.ErrorCode() != -32005 || svc.calls != 1 {
+		t.Errorf("expected rate limit error from a single call but got %v from %d calls", err, svc.calls)
+	}
+}
+
+// pendingService is an in-process eth service which notifies each subscriber of a fixed list of hashes.
+type pendingService struct {
+	hashes []common.Hash
+}
+
+func (s *pendingService) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for _, h := range s.hashes {
+			if err := notifier.Notify(sub.ID, h); err != nil {
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func TestClient_SubscribePendingTransactions(t *testing.T) {
+	svc := &pendingService{hashes: []common.Hash{{1}, {2}, {3}}}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+
+	ch := make(chan common.Hash)
+	sub, err := c.SubscribePendingTransactions(context.Background(), ch)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, exp := range svc.hashes {
+		select {
+		case got := <-ch:
+			if got != exp {
+				t.Errorf("expected %s but got %s", exp.Hex(), got.Hex())
+			}
+		case err := <-sub.Err():
+			t.Fatalf("unexpected subscription error: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for notification")
+		}
+	}
+	sub.Unsubscribe()
+	if _, ok := <-sub.Err(); ok {
+		t.Error("expected error channel to be closed after unsubscribe")
+	}
+}
+
+// callService is an in-process eth service which echoes the arguments of eth_call.
+type callService struct {
+	block     interface{}
+	overrides map[common.Address]map[string]interface{}
+}
+
+func (s *callService) Call(msg map[string]interface{}, block interface{}, overrides *map[common.Address]map[string]interface{}) (hexutil.Bytes, error) {
+	s.block = block
+	if overrides != nil {
+		s.overrides = *overrides
+	}
+	return hexutil.Bytes{1}, nil
+}
+
+func TestClient_CallAt(t *testing.T) {
+	svc := &callService{}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+	ctx := context.Background()
+	to := common.Address{1}
+
+	if _, err := c.CallAt(ctx, CallMsg{To: &to}, BlockNumber(big.NewInt(16)), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc.block != "0x10" {
+		t.Errorf("expected block 0x10 but got %v", svc.block)
+	}
+	if svc.overrides != nil {
+		t.Errorf("expected no overrides but got %v", svc.overrides)
+	}
+
+	hash := common.Hash{2}
+	balance := big.NewInt(1000)
+	overrides := StateOverride{to: {Balance: balance}}
+	if _, err := c.CallAt(ctx, CallMsg{To: &to}, BlockHash(hash), overrides); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m, ok := svc.block.(map[string]interface{}); !ok || m["blockHash"] != hash.Hex() {
+		t.Errorf("expected block hash %s but got %v", hash.Hex(), svc.block)
+	}
+	if got := svc.overrides[to]["balance"]; got != hexutil.EncodeBig(balance) {
+		t.Errorf("expected balance override %s but got %v", hexutil.EncodeBig(balance), got)
+	}
+}
+
+// revertService is an in-process eth service whose calls revert with data. It records the
+// block of the last call.
+type revertService struct {
+	data  hexutil.Bytes
+	block string
+}
+
+type revertError struct {
+	data hexutil.Bytes
+}
+
+func (e *revertError) Error() string          { return "execution reverted" }
+func (e *revertError) ErrorCode() int         { return 3 }
+func (e *revertError) ErrorData() interface{} { return e.data.String() }
+
+func (s *revertService) Call(msg map[string]interface{}, block string) (hexutil.Bytes, error) {
+	s.block = block
+	return nil, &revertError{data: s.data}
+}
+
+func (s *revertService) GetTransactionByHash(hash common.Hash) *Transaction {
+	return &Transaction{Hash: hash, From: common.Address{2}, To: &common.Address{1}, GasLimit: 50000,
+		GasPrice: big.NewInt(1), Value: new(big.Int), V: new(big.Int), R: new(big.Int), S: new(big.Int),
+		BlockNumber: big.NewInt(16)}
+}
+
+func TestClient_Call_revert(t *testing.T) {
+	// Error("not owner")
+	data := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000009" +
+		"6e6f74206f776e65720000000000000000000000000000000000000000000000")
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", &revertService{data: data}); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+
+	_, err := c.Call(context.Background(), CallMsg{To: &common.Address{1}})
+	rerr, ok := err.(*RevertError)
+	if !ok {
+		t.Fatalf("expected *RevertError but got %T: %v", err, err)
+	}
+	if rerr.Reason != "not owner" {
+		t.Errorf("expected reason %q but got %q", "not owner", rerr.Reason)
+	}
+	if !bytes.Equal(rerr.Data, data) {
+		t.Errorf("expected data %x but got %x", data, rerr.Data)
+	}
+}
+
+func TestGetRevertReason(t *testing.T) {
+	// Error("not owner")
+	data := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000009" +
+		"6e6f74206f776e65720000000000000000000000000000000000000000000000")
+	svc := &revertService{data: data}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+
+	rerr, err := GetRevertReason(context.Background(), c, common.Hash{1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rerr.Reason != "not owner" {
+		t.Errorf("expected reason %q but got %q", "not owner", rerr.Reason)
+	}
+	// The transaction is replayed on the state before its block, 16.
+	if svc.block != "0xf" {
+		t.Errorf("expected call at block 0xf but got %s", svc.block)
+	}
+}
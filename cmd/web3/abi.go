@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/web3"
+)
+
+// AbiEncode prints the call data for a function call, or the encoded arguments for a signature without a name.
+func AbiEncode(sig string, args []string) {
+	if sig == "" {
+		fatalExit(errors.New("Missing signature argument. eg: `web3 abi encode \"transfer(address,uint256)\" 0xADDRESS 1000`"))
+	}
+	params := make([]interface{}, len(args))
+	for i, a := range args {
+		params[i] = a
+	}
+	data, err := web3.EncodeCallData(sig, params...)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot encode %s: %v", sig, err))
+	}
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(hexutil.Bytes(data)))
+		return
+	}
+	fmt.Println(hexutil.Encode(data))
+}
+
+// AbiDecode prints values of the given types, such as "(uint256,address)", decoded from hex data.
+func AbiDecode(types, data string) {
+	if types == "" || data == "" {
+		fatalExit(errors.New("Missing arguments. eg: `web3 abi decode \"(uint256,address)\" 0xDATA`"))
+	}
+	types = strings.TrimSpace(types)
+	if strings.HasPrefix(types, "(") && strings.HasSuffix(types, ")") {
+		types = types[1 : len(types)-1]
+	}
+	args, err := web3.ParseArguments(types)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid types %q: %v", types, err))
+	}
+	b, err := hexutil.Decode(data)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid hex data: %v", err))
+	}
+	switch format {
+	case "json":
+		vals, err := args.UnpackValues(b)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot decode data: %v", err))
+		}
+		fmt.Println(marshalJSON(web3.NamedOutputs(args, vals)))
+		return
+	}
+	vals, err := web3.DecodeArguments(args, b)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot decode data: %v", err))
+	}
+	printCallArgs(vals)
+}
+
+// AbiSelector prints the 4 byte selector of a function or error signature.
+func AbiSelector(sig string) {
+	canonical := canonicalSignature(sig)
+	selector := hexutil.Encode(crypto.Keccak256([]byte(canonical))[:4])
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]string{"signature": canonical, "selector": selector}))
+		return
+	}
+	fmt.Println(selector, canonical)
+}
+
+// AbiTopic prints the topic of an event signature.
+func AbiTopic(sig string) {
+	canonical := canonicalSignature(sig)
+	topic := crypto.Keccak256Hash([]byte(canonical)).Hex()
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]string{"signature": canonical, "topic": topic}))
+		return
+	}
+	fmt.Println(topic, canonical)
+}
+
+func canonicalSignature(sig string) string {
+	if sig == "" {
+		fatalExit(errors.New("Missing signature argument. eg: `web3 abi selector \"transfer(address,uint256)\"`"))
+	}
+	canonical, err := web3.CanonicalSignature(sig)
+	if err != nil {
+		fatalExit(err)
+	}
+	return canonical
+}
+
+// AbiDiff prints the differences between an old and new ABI, exiting with status exitBreaking if any are breaking.
+func AbiDiff(oldFile, newFile string) {
+	if oldFile == "" || newFile == "" {
+		fatalExit(errors.New("Missing arguments. eg: `web3 abi diff old.abi new.abi`"))
+	}
+	changes := diffABIFiles(oldFile, newFile)
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(changes))
+	default:
+		if len(changes) == 0 {
+			fmt.Println("No changes")
+		}
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	}
+	if web3.BreakingChanges(changes) {
+		os.Exit(exitBreaking)
+	}
+}
+
+// diffABIFiles compares the functions, events and errors of two ABI files, with breaking changes first.
+func diffABIFiles(oldFile, newFile string) []web3.ABIChange {
+	oldABI, oldErrs, err := web3.GetABIWithErrors(oldFile)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get ABI %q: %v", oldFile, err))
+	}
+	newABI, newErrs, err := web3.GetABIWithErrors(newFile)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get ABI %q: %v", newFile, err))
+	}
+	changes := append(web3.DiffABI(*oldABI, *newABI), web3.DiffErrors(oldErrs, newErrs)...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Breaking && !changes[j].Breaking
+	})
+	return changes
+}
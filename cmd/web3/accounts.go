@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts"
+	"github.com/gochain/gochain/v4/accounts/keystore"
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/console/prompt"
+	"github.com/gochain/web3"
+	"github.com/urfave/cli"
+)
+
+// Keystore signing options, set by the global --keystore, --from and --password-file flags.
+var (
+	keystoreDir  string
+	fromAddress  string
+	passwordFile string
+)
+
+// setKeystoreFlags sets the keystore options from the flags of an account command,
+// which override the global flags.
+func setKeystoreFlags(c *cli.Context) {
+	for name, dest := range map[string]*string{"keystore": &keystoreDir, "from": &fromAddress, "password-file": &passwordFile} {
+		if c.IsSet(name) {
+			*dest = c.String(name)
+		}
+	}
+}
+
+// keystoreSigner is the signer opened by getSigner, so the keystore is only unlocked once.
+var keystoreSigner *web3.KeystoreSigner
+
+// getSigner returns the signer for transactions: the --from account of the --keystore directory
+// if set, otherwise the private key. It exits if neither is valid.
+func getSigner(privateKey string) web3.Signer {
+	if keystoreDir == "" {
+		if privateKey == "" {
+			fatalExit(fmt.Errorf("Missing private key. Set %s, --private-key, or --keystore and --from", pkVarName))
+		}
+		acct, err := web3.ParsePrivateKey(privateKey)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot parse private key: %v", err))
+		}
+		return acct
+	}
+	if keystoreSigner != nil {
+		return keystoreSigner
+	}
+	from := keystoreAccount(keystoreDir, fromAddress)
+	signer, err := web3.NewKeystoreSigner(keystoreDir, from.Address, readPassword(passwordFile, "Password: "))
+	if err != nil {
+		fatalExit(err)
+	}
+	keystoreSigner = signer
+	return signer
+}
+
+// signerAddress returns the address of the signer for transactions, without unlocking a keystore,
+// or "" if there is none.
+func signerAddress(privateKey string) string {
+	if keystoreDir != "" {
+		return keystoreAccount(keystoreDir, fromAddress).Address.Hex()
+	}
+	if privateKey == "" {
+		return ""
+	}
+	acct, err := web3.ParsePrivateKey(privateKey)
+	if err != nil {
+		fatalExit(err)
+	}
+	return acct.PublicKey()
+}
+
+// openKeystore opens the keystore directory dir, exiting if it's unset.
+func openKeystore(dir string) *keystore.KeyStore {
+	if dir == "" {
+		fatalExit(errors.New("Missing keystore directory. Set --keystore"))
+	}
+	return keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+// keystoreAccount returns the from account of the keystore directory dir, which may be omitted if
+// the keystore holds a single account.
+func keystoreAccount(dir, from string) accounts.Account {
+	ks := openKeystore(dir)
+	if from == "" {
+		accts := ks.Accounts()
+		if len(accts) != 1 {
+			fatalExit(fmt.Errorf("Missing account. Set --from to one of the %d accounts in %q", len(accts), dir))
+		}
+		return accts[0]
+	}
+	if !common.IsHexAddress(from) {
+		fatalExit(fmt.Errorf("Invalid from address: %q", from))
+	}
+	acct, err := ks.Find(accounts.Account{Address: common.HexToAddress(from)})
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot find %s in keystore %q: %v", from, dir, err))
+	}
+	return acct
+}
+
+// stdinLines reads secrets piped to stdin. All of them are read with it, since a reader of its own for
+// each would buffer, and lose, the lines following its own.
+var stdinLines = bufio.NewReader(os.Stdin)
+
+// stdinIsTerminal reports whether stdin is a terminal rather than piped or redirected.
+var stdinIsTerminal = func() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readSecret prompts for a secret without echoing it if stdin is a terminal, or else reads the next
+// line of stdin.
+func readSecret(promptText string) (string, error) {
+	if stdinIsTerminal() {
+		return prompt.Stdin.PromptPassword(promptText)
+	}
+	line, err := stdinLines.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// readPassword returns the first line of file, or prompts for the password if file is unset.
+func readPassword(file, promptText string) string {
+	if file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read password file: %v", err))
+		}
+		return strings.TrimRight(strings.SplitN(string(b), "\n", 2)[0], "\r")
+	}
+	password, err := readSecret(promptText)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot read password: %v", err))
+	}
+	return password
+}
+
+// readNewPassword is like readPassword, but prompts twice to confirm the password.
+// Piped passwords aren't confirmed.
+func readNewPassword(file, promptText string) string {
+	password := readPassword(file, promptText)
+	if file == "" && stdinIsTerminal() {
+		if readPassword("", "Repeat password: ") != password {
+			fatalExit(errors.New("Passwords do not match"))
+		}
+	}
+	return password
+}
+
+// AccountNew creates a new account in the keystore directory dir.
+func AccountNew(dir string) {
+	ks := openKeystore(dir)
+	acct, err := ks.NewAccount(readNewPassword(passwordFile, "Password: "))
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot create account: %v", err))
+	}
+	printKeystoreAccount(acct)
+}
+
+// AccountImport imports a private key, read from stdin or prompted for, into the keystore directory dir.
+// The key isn't accepted as a flag or environment variable, which could leak it to other processes.
+// Piped to stdin, the key is read from the first line, and the password from the next unless passwordFile is set.
+func AccountImport(dir string) {
+	ks := openKeystore(dir)
+	privateKey, err := readSecret("Private key: ")
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot read private key: %v", err))
+	}
+	key, err := web3.ParsePrivateKey(privateKey)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot parse private key: %v", err))
+	}
+	acct, err := ks.ImportECDSA(key.Key(), readNewPassword(passwordFile, "Password: "))
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot import account: %v", err))
+	}
+	printKeystoreAccount(acct)
+}
+
+// AccountExport writes the from account of the keystore directory dir to outFile (or stdout),
+// encrypted with a new password.
+func AccountExport(dir, from, newPasswordFile, outFile string) {
+	ks := openKeystore(dir)
+	acct := keystoreAccount(dir, from)
+	password := readPassword(passwordFile, "Password: ")
+	newPassword := readNewPassword(newPasswordFile, "New password: ")
+	keyJSON, err := ks.Export(acct, password, newPassword)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot export account: %v", err))
+	}
+	if outFile == "" {
+		fmt.Println(string(keyJSON))
+		return
+	}
+	if err := ioutil.WriteFile(outFile, keyJSON, 0600); err != nil {
+		fatalExit(fmt.Errorf("Cannot write keystore file: %v", err))
+	}
+	fmt.Println("Keystore file:", outFile)
+}
+
+// AccountList prints the accounts in the keystore directory dir.
+func AccountList(dir string) {
+	accts := openKeystore(dir).Accounts()
+	switch format {
+	case "json":
+		type account struct {
+			Address common.Address `json:"address"`
+			File    string         `json:"file"`
+		}
+		list := make([]account, len(accts))
+		for i, acct := range accts {
+			list[i] = account{Address: acct.Address, File: acct.URL.Path}
+		}
+		fmt.Println(marshalJSON(list))
+		return
+	}
+	if len(accts) == 0 {
+		fmt.Fprintf(os.Stderr, "No accounts in %q\n", dir)
+		return
+	}
+	for _, acct := range accts {
+		fmt.Println(acct.Address.Hex(), acct.URL.Path)
+	}
+}
+
+func printKeystoreAccount(acct accounts.Account) {
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]interface{}{"address": acct.Address, "file": acct.URL.Path}))
+		return
+	}
+	fmt.Printf("Public address: %v\n", acct.Address.Hex())
+	fmt.Printf("Keystore file: %v\n", acct.URL.Path)
+}
+
+// AccountCreateMnemonic creates a new BIP-39 mnemonic of the given number of words, and prints it
+// with the address of its first account. The account is imported into the keystore directory dir
+// if set, and its private key is only printed if showKey is set.
+func AccountCreateMnemonic(words int, passphrase string, showKey bool, dir string) {
+	mnemonic, err := web3.NewMnemonic(words)
+	if err != nil {
+		fatalExit(err)
+	}
+	seed, err := web3.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		fatalExit(err)
+	}
+	acct := deriveAccounts(seed, []accounts.DerivationPath{web3.DefaultHDPath}, showKey, dir)[0]
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(struct {
+			Mnemonic string `json:"mnemonic"`
+			derivedAccount
+		}{mnemonic, acct}))
+		return
+	}
+	fmt.Printf("Mnemonic: %v\n", mnemonic)
+	printDerivedAccount(acct)
+}
+
+// AccountDerive prints the addresses of count accounts of a BIP-39 mnemonic, starting at index,
+// or of the single account at path if set. The mnemonic is prompted for if it's unset. The accounts
+// are imported into the keystore directory dir if set, and their private keys are only printed if
+// showKey is set.
+func AccountDerive(mnemonic, passphrase, path string, index, count uint32, showKey bool, dir string) {
+	if mnemonic == "" {
+		var err error
+		mnemonic, err = readSecret("Mnemonic: ")
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read mnemonic: %v", err))
+		}
+	}
+	seed, err := web3.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		fatalExit(err)
+	}
+	var paths []accounts.DerivationPath
+	if path != "" {
+		p, err := web3.ParseHDPath(path)
+		if err != nil {
+			fatalExit(err)
+		}
+		paths = append(paths, p)
+	} else {
+		for i := uint32(0); i < count; i++ {
+			paths = append(paths, web3.HDPath(index+i))
+		}
+	}
+	list := deriveAccounts(seed, paths, showKey, dir)
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(list))
+		return
+	}
+	for i, acct := range list {
+		if i > 0 {
+			fmt.Println()
+		}
+		printDerivedAccount(acct)
+	}
+}
+
+// derivedAccount is an account derived from a mnemonic.
+type derivedAccount struct {
+	Path       string         `json:"path"`
+	Address    common.Address `json:"address"`
+	PrivateKey string         `json:"privateKey,omitempty"`
+	File       string         `json:"file,omitempty"` // keystore file
+}
+
+// deriveAccounts derives the accounts of seed at paths, and imports them into the keystore
+// directory dir if set. Their private keys are only included if showKey is set.
+func deriveAccounts(seed []byte, paths []accounts.DerivationPath, showKey bool, dir string) []derivedAccount {
+	var ks *keystore.KeyStore
+	var password string
+	if dir != "" {
+		ks = openKeystore(dir)
+		password = readNewPassword(passwordFile, "Password: ")
+	}
+	list := make([]derivedAccount, len(paths))
+	for i, p := range paths {
+		acct, err := web3.DeriveAccount(seed, p)
+		if err != nil {
+			fatalExit(err)
+		}
+		list[i] = derivedAccount{Path: p.String(), Address: acct.Address()}
+		if showKey {
+			list[i].PrivateKey = acct.PrivateKey()
+		}
+		if ks != nil {
+			imported, err := ks.ImportECDSA(acct.Key(), password)
+			if err != nil {
+				fatalExit(fmt.Errorf("Cannot import account %s: %v", acct.Address().Hex(), err))
+			}
+			list[i].File = imported.URL.Path
+		}
+	}
+	return list
+}
+
+func printDerivedAccount(acct derivedAccount) {
+	fmt.Printf("Path: %v\n", acct.Path)
+	if acct.PrivateKey != "" {
+		fmt.Printf("Private key: %v\n", acct.PrivateKey)
+	}
+	fmt.Printf("Public address: %v\n", acct.Address.Hex())
+	if acct.File != "" {
+		fmt.Printf("Keystore file: %v\n", acct.File)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/gochain/web3"
+)
+
+func TestAccountImport_Piped(t *testing.T) {
+	const pk = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	acct, err := web3.ParsePrivateKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(r *bufio.Reader, isTerminal func() bool) {
+		stdinLines, stdinIsTerminal = r, isTerminal
+	}(stdinLines, stdinIsTerminal)
+	stdinIsTerminal = func() bool { return false }
+	stdinLines = bufio.NewReader(strings.NewReader(pk + "\r\nsecret\n"))
+
+	dir := t.TempDir()
+	AccountImport(dir)
+
+	accts := openKeystore(dir).Accounts()
+	if len(accts) != 1 || accts[0].Address != acct.Address() {
+		t.Fatalf("expected account %s but got %v", acct.Address().Hex(), accts)
+	}
+	if _, err := openKeystore(dir).Export(accts[0], "secret", "secret"); err != nil {
+		t.Errorf("expected the piped password to unlock the account: %v", err)
+	}
+}
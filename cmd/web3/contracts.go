@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/common"
 	"github.com/gochain/web3"
 )
+
 func ListContract(contractFile string) {
 	myabi, err := web3.GetABI(contractFile)
 	if err != nil {
@@ -25,7 +31,8 @@ func ListContract(contractFile string) {
 	}
 }
 
-func GetContractConst(ctx context.Context, rpcURL, contractAddress, contractFile, functionName string, parameters ...interface{}) ([]interface{}, error) {
+func GetContractConst(ctx context.Context, rpcURL, contractAddress, contractFile string, block web3.BlockNumberOrHash,
+	functionName string, parameters ...interface{}) ([]interface{}, error) {
 	client, err := web3.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to connect to %q: %v", rpcURL, err)
@@ -42,7 +49,7 @@ func GetContractConst(ctx context.Context, rpcURL, contractAddress, contractFile
 	if !fn.IsConstant() {
 		return nil, err
 	}
-	res, err := web3.CallConstantFunction(ctx, client, *myabi, contractAddress, functionName, parameters...)
+	res, err := web3.CallConstantFunctionAt(ctx, client, *myabi, contractAddress, block, nil, functionName, parameters...)
 	if err != nil {
 		return nil, fmt.Errorf("Error calling constant function: %v", err)
 	}
@@ -50,16 +57,19 @@ func GetContractConst(ctx context.Context, rpcURL, contractAddress, contractFile
 }
 
 func callContract(ctx context.Context, client web3.Client, privateKey, contractAddress, abiFile, functionName string,
-	amount *big.Int, gasPrice *big.Int, gasLimit uint64, waitForReceipt, toString bool, data []byte, timeoutInSeconds uint64, parameters ...interface{}) {
+	amount *big.Int, opts web3.TxOpts, waitForReceipt, toString bool, block web3.BlockNumberOrHash, overrides web3.StateOverride,
+	data []byte, timeoutInSeconds uint64, parameters ...interface{}) {
 
 	var err error
 	var tx *web3.Transaction
 	var myabi *abi.ABI
+	var abiErrs []web3.ABIError
 	if len(data) > 0 {
-		tx, err = web3.CallFunctionWithData(ctx, client, privateKey, contractAddress, amount, gasPrice, gasLimit, data)
+		signer := getSigner(privateKey)
+		tx, err = web3.CallFunctionDataWithSigner(ctx, client, signer, contractAddress, amount, withNonces(client, signer, opts), data)
 	} else {
 		// var m abi.Method
-		myabi, err = web3.GetABI(abiFile)
+		myabi, abiErrs, err = web3.GetABIWithErrors(abiFile)
 		if err != nil {
 			fatalExit(err)
 		}
@@ -71,19 +81,13 @@ func callContract(ctx context.Context, client web3.Client, privateKey, contractA
 		}
 
 		if m.IsConstant() {
-			res, err := web3.CallConstantFunction(ctx, client, *myabi, contractAddress, functionName, parameters...)
+			res, err := web3.CallConstantFunctionAt(ctx, client, *myabi, contractAddress, block, overrides, functionName, parameters...)
 			if err != nil {
-				fatalExit(fmt.Errorf("Error calling constant function: %v", err))
+				fatalExit(fmt.Errorf("Error calling constant function: %v", web3.DecodeRevertError(err, abiErrs)))
 			}
 			switch format {
 			case "json":
-				m := make(map[string]interface{})
-				if len(res) == 1 {
-					m["response"] = res[0]
-				} else {
-					m["response"] = res
-				}
-				fmt.Println(marshalJSON(m))
+				fmt.Println(marshalJSON(web3.NamedOutputs(m.Outputs, res)))
 				return
 			}
 			if toString {
@@ -101,7 +105,11 @@ func callContract(ctx context.Context, client web3.Client, privateKey, contractA
 			}
 			return
 		}
-		tx, err = web3.CallTransactFunction(ctx, client, *myabi, contractAddress, privateKey, functionName, amount, gasPrice, gasLimit, parameters...)
+		if block != (web3.BlockNumberOrHash{}) || overrides != nil {
+			fatalExit(fmt.Errorf("Cannot call non-constant function %q at a block or with state overrides", functionName))
+		}
+		signer := getSigner(privateKey)
+		tx, err = web3.CallFunctionWithSigner(ctx, client, signer, contractAddress, amount, withNonces(client, signer, opts), *myabi, functionName, parameters...)
 	}
 	if err != nil {
 		fatalExit(fmt.Errorf("Error calling contract: %v", err))
@@ -111,10 +119,120 @@ func callContract(ctx context.Context, client web3.Client, privateKey, contractA
 		return
 	}
 	fmt.Println("Waiting for receipt...")
-	ctx, _ = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		fatalExit(fmt.Errorf("getting receipt: %v", err))
 	}
-	printReceiptDetails(receipt, myabi)
+	printReceiptDetails(ctx, client, receipt, myabi, abiErrs)
+}
+
+// InspectContract prints the standards implemented by a contract, its code size, whether it is a
+// proxy, and the compiler metadata appended to its code.
+func InspectContract(ctx context.Context, rpcURL, contractAddress string) {
+	if !common.IsHexAddress(contractAddress) {
+		fatalExit(fmt.Errorf("Invalid contract address: %q", contractAddress))
+	}
+	address := common.HexToAddress(contractAddress)
+	client, err := web3.Dial(rpcURL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", rpcURL, err))
+	}
+	defer client.Close()
+	code, err := client.GetCode(ctx, address.Hex(), nil)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get address code from the network: %v", err))
+	}
+	if len(code) == 0 {
+		fatalExit(fmt.Errorf("There is no contract at %s", address.Hex()))
+	}
+	standards, err := web3.DetectStandards(ctx, client, address)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot detect standards: %v", err))
+	}
+	proxy, err := web3.ResolveProxy(ctx, client, address)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot resolve the proxy: %v", err))
+	}
+	metadata, _ := web3.ParseCodeMetadata(code)
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(struct {
+			Address   common.Address     `json:"address"`
+			CodeSize  int                `json:"codeSize"`
+			Standards []string           `json:"standards"`
+			Proxy     *web3.Proxy        `json:"proxy,omitempty"`
+			Metadata  *web3.CodeMetadata `json:"metadata,omitempty"`
+		}{address, len(code), standards, proxy, metadata}))
+		return
+	}
+	fmt.Println("Address:", address.Hex())
+	fmt.Println("Code size:", len(code), "bytes")
+	if len(standards) > 0 {
+		fmt.Println("Standards:", strings.Join(standards, ", "))
+	} else {
+		fmt.Println("Standards: none detected")
+	}
+	if proxy != nil {
+		fmt.Printf("Proxy: %s proxy for %s\n", proxy.Kind, proxy.Implementation.Hex())
+		if proxy.Admin != nil {
+			fmt.Println("Proxy admin:", proxy.Admin.Hex())
+		}
+		if proxy.Beacon != nil {
+			fmt.Println("Proxy beacon:", proxy.Beacon.Hex())
+		}
+	} else {
+		fmt.Println("Proxy: none detected")
+	}
+	if metadata != nil {
+		compiler := metadata.Compiler
+		if metadata.Version != "" {
+			compiler += " " + metadata.Version
+		}
+		if metadata.Experimental {
+			compiler += " (experimental)"
+		}
+		fmt.Println("Compiler:", compiler)
+		if metadata.IPFS != "" {
+			fmt.Println("Metadata IPFS hash: 0x" + metadata.IPFS)
+		}
+		if metadata.Swarm != "" {
+			fmt.Println("Metadata Swarm hash: 0x" + metadata.Swarm)
+		}
+	}
+}
+
+// getVerifiedABI returns the JSON ABI of a verified contract from an Etherscan compatible explorer API.
+func getVerifiedABI(ctx context.Context, explorerURL string, address common.Address) string {
+	if explorerURL == "" {
+		fatalExit(errors.New("Missing --abi or --explorer-api to fetch the implementation's ABI from"))
+	}
+	sep := "?"
+	if strings.Contains(explorerURL, "?") {
+		sep = "&"
+	}
+	url := explorerURL + sep + "module=contract&action=getabi&address=" + address.Hex()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot create the request: %v", err))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get the ABI of %s: %v", address.Hex(), err))
+	}
+	defer resp.Body.Close()
+	var res struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Result  string `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		fatalExit(fmt.Errorf("Cannot get the ABI of %s: invalid response (%s): %v", address.Hex(), resp.Status, err))
+	}
+	if res.Status != "1" {
+		fatalExit(fmt.Errorf("Cannot get the ABI of %s: %s: %s", address.Hex(), res.Message, res.Result))
+	}
+	return res.Result
 }
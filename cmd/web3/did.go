@@ -28,7 +28,7 @@ import (
 // MaxDIDLength is the maximum size of the idstring of the GoChain DID.
 const MaxDIDLength = 32
 
-func CreateDID(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, id, registryAddress string, timeoutInSeconds uint64) {
+func CreateDID(ctx context.Context, rpcURL string, chainID *big.Int, signer web3.Signer, id, registryAddress string, timeoutInSeconds uint64) {
 	if registryAddress == "" {
 		log.Fatalf("Registry contract address required")
 	} else if id == "" {
@@ -44,12 +44,10 @@ func CreateDID(ctx context.Context, rpcURL string, chainID *big.Int, privateKey,
 		log.Fatalf("ID must be less than 32 characters")
 	}
 
-	// Parse key.
-	acc, err := web3.ParsePrivateKey(privateKey)
+	publicKey, err := web3.SignerPublicKey(ctx, signer)
 	if err != nil {
-		log.Fatalf("Cannot parse private key: %s", err)
+		log.Fatalf("Cannot get public key: %s", err)
 	}
-	publicKey := acc.Key().PublicKey
 
 	// Build DID identifier.
 	publicKeyID := *d
@@ -65,7 +63,7 @@ func CreateDID(ctx context.Context, rpcURL string, chainID *big.Int, privateKey,
 		ID:           publicKeyID.String(),
 		Type:         "Secp256k1VerificationKey2018",
 		Controller:   d.String(),
-		PublicKeyHex: common.ToHex(crypto.FromECDSAPub(&publicKey)),
+		PublicKeyHex: common.ToHex(crypto.FromECDSAPub(publicKey)),
 	}}
 	doc.Authentications = []interface{}{publicKeyID.String()}
 
@@ -96,12 +94,13 @@ func CreateDID(ctx context.Context, rpcURL string, chainID *big.Int, privateKey,
 	var idBytes32 [32]byte
 	copy(idBytes32[:], d.ID)
 
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, registryAddress, privateKey, "register", &big.Int{}, nil, 70000, idBytes32, hash)
+	tx, err := web3.CallFunctionWithSigner(ctx, client, signer, registryAddress, &big.Int{}, withNonces(client, signer, web3.TxOpts{}), myabi, "register", idBytes32, hash)
 	if err != nil {
 		log.Fatalf("Cannot register DID identifier: %v", err)
 	}
 
-	ctx, _ = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the receipt for transaction with hash '%v': %v", tx.Hash.Hex(), err)
@@ -201,7 +200,7 @@ func ShowDID(ctx context.Context, rpcURL, privateKey, id, registryAddress string
 	fmt.Println(string(data))
 }
 
-func SignClaim(ctx context.Context, rpcURL, privateKey, id, typ, issuerID, subjectID, subjectJSON string) {
+func SignClaim(ctx context.Context, rpcURL string, signer web3.Signer, id, typ, issuerID, subjectID, subjectJSON string) {
 	if id == "" {
 		log.Fatalf("Credential ID required")
 	} else if typ == "" {
@@ -218,12 +217,6 @@ func SignClaim(ctx context.Context, rpcURL, privateKey, id, typ, issuerID, subje
 		log.Fatalf("Invalid credential subject DID: %s", err)
 	}
 
-	// Parse key.
-	acc, err := web3.ParsePrivateKey(privateKey)
-	if err != nil {
-		log.Fatalf("Cannot parse private key: %s", err)
-	}
-
 	// Parse subject object.
 	subject := make(map[string]interface{})
 	if subjectJSON != "" {
@@ -253,7 +246,7 @@ func SignClaim(ctx context.Context, rpcURL, privateKey, id, typ, issuerID, subje
 	// Sign hash of credential document.
 	var h common.Hash
 	hw.Sum(h[:0])
-	proofValue, err := crypto.Sign(h[:], acc.Key())
+	proofValue, err := signer.SignHash(ctx, h)
 	if err != nil {
 		log.Fatalf("Cannot sign credential: %s", err)
 	}
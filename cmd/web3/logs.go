@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/core/types"
+	"github.com/gochain/web3"
+)
+
+// GetLogs prints the logs matching the given filter options.
+func GetLogs(ctx context.Context, network web3.Network, fromBlock, toBlock, blockHash string, addresses, topics []string, abiFile string) {
+	var q web3.FilterQuery
+	var err error
+	if blockHash != "" {
+		h := common.HexToHash(blockHash)
+		q.BlockHash = &h
+	}
+	if fromBlock != "" {
+		q.FromBlock, err = web3.ParseBigInt(fromBlock)
+		if err != nil {
+			fatalExit(fmt.Errorf("From block must be a number (decimal integer) %q: %v", fromBlock, err))
+		}
+	}
+	if toBlock != "" && toBlock != "latest" {
+		q.ToBlock, err = web3.ParseBigInt(toBlock)
+		if err != nil {
+			fatalExit(fmt.Errorf("To block must be a number (decimal integer) %q: %v", toBlock, err))
+		}
+	}
+	for _, a := range addresses {
+		if !common.IsHexAddress(a) {
+			fatalExit(fmt.Errorf("Invalid address: %s", a))
+		}
+		q.Addresses = append(q.Addresses, common.HexToAddress(a))
+	}
+	// Each topic flag is one position; comma separated values are alternatives, and an
+	// empty value matches anything.
+	for _, t := range topics {
+		var alts []common.Hash
+		for _, s := range strings.Split(t, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				alts = append(alts, common.HexToHash(s))
+			}
+		}
+		q.Topics = append(q.Topics, alts)
+	}
+	var myabi *abi.ABI
+	if abiFile != "" {
+		myabi, err = web3.GetABI(abiFile)
+		if err != nil {
+			fatalExit(err)
+		}
+	}
+
+	client, err := web3.Dial(network.URL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
+	}
+	defer client.Close()
+	logs, err := client.GetLogs(ctx, q)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get logs from the network: %v", err))
+	}
+	switch format {
+	case "json":
+		if myabi != nil {
+			// Print the other events, and report the logs which failed to parse.
+			parsed, err := web3.ParseLogs(*myabi, logs)
+			if errs, ok := err.(web3.LogErrors); ok {
+				for _, e := range errs {
+					fmt.Fprintf(os.Stderr, "ERROR: Cannot parse the log: %v\n", e)
+				}
+			}
+			fmt.Println(marshalJSON(parsed))
+			return
+		}
+		fmt.Println(marshalJSON(logs))
+		return
+	}
+
+	for _, l := range logs {
+		fmt.Printf("Block: #%d Tx: %s Index: %d\n", l.BlockNumber, l.TxHash.Hex(), l.Index)
+		fmt.Println("Address:", l.Address.Hex())
+		e, err := parseLog(myabi, l)
+		if err != nil {
+			fmt.Printf("ERROR: Cannot parse the log: %v\n", err)
+		}
+		if e != nil {
+			fmt.Println("Event:", marshalJSON(e))
+		}
+		if e == nil || myabi == nil {
+			for j, t := range l.Topics {
+				fmt.Printf("Topic %d: %s\n", j, t.Hex())
+			}
+			fmt.Println("Data:", common.ToHex(l.Data))
+		}
+		fmt.Println()
+	}
+}
+
+// parseLog parses l with myabi, if given, falling back to known signatures for logs of other events.
+// It returns nil if the event is unknown.
+func parseLog(myabi *abi.ABI, l *types.Log) (*web3.Event, error) {
+	if myabi != nil {
+		e, err := web3.ParseLog(*myabi, l)
+		if err != web3.ErrUnknownEvent {
+			return e, err
+		}
+	}
+	if e, ok := signatureDB().ParseLog(l); ok {
+		return &e, nil
+	}
+	return nil, nil
+}
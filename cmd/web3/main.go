@@ -16,6 +16,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -46,12 +47,18 @@ const (
 ( (_-. )(_)(( (__  ) _ (  /(__)\  _)(_  )  ( 
  \___/(_____)\___)(_) (_)(__)(__)(____)(_)\_)`
 
-	pkVarName          = "WEB3_PRIVATE_KEY"
-	addrVarName        = "WEB3_ADDRESS"
-	networkVarName     = "WEB3_NETWORK"
-	rpcURLVarName      = "WEB3_RPC_URL"
-	didRegistryVarName = "WEB3_DID_REGISTRY"
-	langUsing          = "WEB3_LANGUAGE"
+	pkVarName           = "WEB3_PRIVATE_KEY"
+	addrVarName         = "WEB3_ADDRESS"
+	networkVarName      = "WEB3_NETWORK"
+	rpcURLVarName       = "WEB3_RPC_URL"
+	didRegistryVarName  = "WEB3_DID_REGISTRY"
+	langUsing           = "WEB3_LANGUAGE"
+	signaturesVarName   = "WEB3_SIGNATURES"
+	keystoreVarName     = "WEB3_KEYSTORE"
+	fromVarName         = "WEB3_FROM"
+	passwordFileVarName = "WEB3_PASSWORD_FILE"
+	mnemonicVarName     = "WEB3_MNEMONIC"
+	passphraseVarName   = "WEB3_MNEMONIC_PASSPHRASE"
 )
 
 func main() {
@@ -108,6 +115,30 @@ func main() {
 			Usage:       "Output format. Options: json. Default: human readable output.",
 			Destination: &format,
 			Hidden:      false},
+		cli.Float64Flag{
+			Name:        "gas-multiplier",
+			Usage:       "Safety multiplier applied to gas estimates when no gas limit is set.",
+			Value:       web3.GasLimitMultiplier,
+			Destination: &web3.GasLimitMultiplier,
+			Hidden:      false},
+		cli.StringFlag{
+			Name:        "keystore",
+			Usage:       "Keystore directory to sign transactions with, instead of a private key",
+			Destination: &keystoreDir,
+			EnvVar:      keystoreVarName,
+			Hidden:      false},
+		cli.StringFlag{
+			Name:        "from",
+			Usage:       "Address of the keystore account to sign with. Optional if the keystore holds a single account",
+			Destination: &fromAddress,
+			EnvVar:      fromVarName,
+			Hidden:      false},
+		cli.StringFlag{
+			Name:        "password-file",
+			Usage:       "File holding the keystore password. Prompted for if omitted",
+			Destination: &passwordFile,
+			EnvVar:      passwordFileVarName,
+			Hidden:      false},
 	}
 	var network web3.Network
 	app.Before = func(*cli.Context) error {
@@ -132,9 +163,13 @@ func main() {
 					Destination: &txInputFormat,
 					Value:       "len",
 				},
+				cli.StringFlag{
+					Name:  "abi",
+					Usage: "ABI file (or bundled erc20/erc721/erc1155/erc4626/erc2612) to decode transaction input data with",
+				},
 			},
 			Action: func(c *cli.Context) {
-				GetBlockDetails(ctx, network, c.Args().First(), txFormat, txInputFormat)
+				GetBlockDetails(ctx, network, c.Args().First(), txFormat, txInputFormat, c.String("abi"))
 			},
 		},
 		{
@@ -148,9 +183,124 @@ func main() {
 					Destination: &txInputFormat,
 					Value:       "len",
 				},
+				cli.StringFlag{
+					Name:  "abi",
+					Usage: "ABI file (or bundled erc20/erc721/erc1155/erc4626/erc2612) to decode transaction input data with",
+				},
 			},
 			Action: func(c *cli.Context) {
-				GetTransactionDetails(ctx, network, c.Args().First(), txInputFormat)
+				GetTransactionDetails(ctx, network, c.Args().First(), txInputFormat, c.String("abi"))
+			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "sign",
+					Usage: "Sign a transaction offline, without connecting to the network. eg: `web3 tx sign --to 0xADDRESS --amount 1 --nonce 0 --gas-limit 21000 --gas-price-gwei 2`",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:        "private-key, pk",
+							Usage:       "The private key",
+							EnvVar:      pkVarName,
+							Destination: &privateKey,
+						},
+						cli.StringFlag{
+							Name:  "to",
+							Usage: "to address. Omit to deploy a contract",
+						},
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "The amount of GO or ETH in decimal format",
+						},
+						cli.StringFlag{
+							Name:  "data",
+							Usage: "Data for smart contract call or deployment in hex",
+						},
+						cli.Uint64Flag{
+							Name:     "nonce",
+							Usage:    "The nonce of the transaction",
+							Required: true,
+						},
+						cli.StringFlag{
+							Name:  "chain-id",
+							Usage: "Chain ID. Defaults to the chain ID of the network",
+						},
+						cli.Uint64Flag{
+							Name:     "gas-limit",
+							Usage:    "Gas limit (multiplied by price for total gas)",
+							Required: true,
+						},
+						cli.StringFlag{
+							Name:  "gas-price",
+							Usage: "Gas price for a legacy transaction",
+						},
+						cli.StringFlag{
+							Name:  "gas-price-gwei",
+							Usage: "Gas price in GWEI for a legacy transaction",
+						},
+						cli.StringFlag{
+							Name:  "max-fee",
+							Usage: "Max fee per gas in GWEI for an EIP-1559 transaction",
+						},
+						cli.StringFlag{
+							Name:  "priority-fee",
+							Usage: "Max priority fee (tip) per gas in GWEI for an EIP-1559 transaction",
+						},
+						cli.StringFlag{
+							Name:  "out",
+							Usage: "File to write the signed transaction to, instead of printing it",
+						},
+					},
+					Action: func(c *cli.Context) {
+						chainID := network.ChainID
+						if s := c.String("chain-id"); s != "" {
+							var ok bool
+							chainID, ok = new(big.Int).SetString(s, 0)
+							if !ok {
+								fatalExit(fmt.Errorf("invalid chain ID %v", s))
+							}
+						}
+						if chainID == nil {
+							fatalExit(errors.New("Missing chain ID. Set --chain-id or a known --network"))
+						}
+						amount := new(big.Int)
+						if a := c.String("amount"); a != "" {
+							amountd, err := decimal.NewFromString(a)
+							if err != nil {
+								fatalExit(fmt.Errorf("invalid amount %v", a))
+							}
+							amount = web3.DecToInt(amountd, 18)
+						}
+						var data []byte
+						if d := c.String("data"); d != "" {
+							var err error
+							data, err = hexutil.Decode(d)
+							if err != nil {
+								fatalExit(fmt.Errorf("invalid data: %v", err))
+							}
+						}
+						opts := parseTxOpts(c)
+						nonce := c.Uint64("nonce")
+						opts.Nonce = &nonce
+						SignTxOffline(ctx, privateKey, chainID, c.String("to"), amount, data, opts, c.String("out"))
+					},
+				},
+				{
+					Name:  "broadcast",
+					Usage: "Send a signed raw transaction, from `web3 tx sign` or elsewhere. eg: `web3 tx broadcast 0xRAW` or `web3 tx broadcast FILE`",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "wait",
+							Usage: "Wait for the receipt of this transaction",
+						},
+						cli.UintFlag{
+							Name:  "timeout",
+							Usage: "Timeout in seconds (default: 60).",
+							Value: 60,
+						},
+					},
+					Action: func(c *cli.Context) {
+						BroadcastTx(ctx, network, c.Args().First(), c.Bool("wait"), c.Uint64("timeout"))
+					},
+				},
 			},
 		},
 		{
@@ -168,6 +318,109 @@ func main() {
 					Hidden:      false},
 			},
 		},
+		{
+			Name:  "logs",
+			Usage: "Logs matching a filter. eg: `web3 logs --from-block 100 --address 0xABC --topic 0xddf2...`",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from-block",
+					Usage: "First block of the range (decimal integer). Default: latest",
+				},
+				cli.StringFlag{
+					Name:  "to-block",
+					Usage: "Last block of the range (decimal integer). Default: latest",
+				},
+				cli.StringFlag{
+					Name:  "block-hash",
+					Usage: "Only logs from the block with this hash. Cannot be combined with a block range",
+				},
+				cli.StringSliceFlag{
+					Name:  "address",
+					Usage: "Contract address emitting the logs. May be repeated",
+				},
+				cli.StringSliceFlag{
+					Name:  "topic",
+					Usage: "Topic for each position, in order. Comma separate alternatives, leave empty to match any. May be repeated",
+				},
+				cli.StringFlag{
+					Name:        "abi",
+					Destination: &abiFile,
+					Usage:       "ABI file used to parse the logs",
+				},
+			},
+			Action: func(c *cli.Context) {
+				GetLogs(ctx, network, c.String("from-block"), c.String("to-block"), c.String("block-hash"),
+					c.StringSlice("address"), c.StringSlice("topic"), abiFile)
+			},
+		},
+		{
+			Name:  "abi",
+			Usage: "ABI encoding tools",
+			Subcommands: []cli.Command{
+				{
+					Name:      "encode",
+					Usage:     "Encode call data for a function, eg: `web3 abi encode \"transfer(address,uint256)\" 0xADDRESS 1000`. Omit the function name to encode only the arguments",
+					ArgsUsage: "SIGNATURE [ARGS...]",
+					Action: func(c *cli.Context) {
+						AbiEncode(c.Args().First(), c.Args().Tail())
+					},
+				},
+				{
+					Name:      "decode",
+					Usage:     "Decode data, such as function return data, eg: `web3 abi decode \"(uint256,address)\" 0xDATA`",
+					ArgsUsage: "TYPES DATA",
+					Action: func(c *cli.Context) {
+						AbiDecode(c.Args().First(), c.Args().Get(1))
+					},
+				},
+				{
+					Name:      "selector",
+					Usage:     "Print the selector of a function or error signature, eg: `web3 abi selector \"transfer(address,uint256)\"`",
+					ArgsUsage: "SIGNATURE",
+					Action: func(c *cli.Context) {
+						AbiSelector(c.Args().First())
+					},
+				},
+				{
+					Name:      "topic",
+					Usage:     "Print the topic of an event signature, eg: `web3 abi topic \"Transfer(address,address,uint256)\"`",
+					ArgsUsage: "SIGNATURE",
+					Action: func(c *cli.Context) {
+						AbiTopic(c.Args().First())
+					},
+				},
+				{
+					Name:      "diff",
+					Usage:     "Compare two versions of an ABI, eg: `web3 abi diff old.abi new.abi`. Exits with status 2 if any change is breaking",
+					ArgsUsage: "OLD NEW",
+					Action: func(c *cli.Context) {
+						AbiDiff(c.Args().First(), c.Args().Get(1))
+					},
+				},
+			},
+		},
+		{
+			Name:  "sig",
+			Usage: "Function and event signature database, used to decode calls and logs without an ABI",
+			Subcommands: []cli.Command{
+				{
+					Name:      "lookup",
+					Usage:     "Look up the signatures for a function selector or event topic. eg: `web3 sig lookup 0xa9059cbb`",
+					ArgsUsage: "SELECTOR_OR_TOPIC",
+					Action: func(c *cli.Context) {
+						LookupSignature(c.Args().First())
+					},
+				},
+				{
+					Name:      "import",
+					Usage:     "Import signatures from a JSON ABI or a file with one `function` or `event` signature per line",
+					ArgsUsage: "FILE",
+					Action: func(c *cli.Context) {
+						ImportSignatures(c.Args().First())
+					},
+				},
+			},
+		},
 		{
 			Name:    "address",
 			Aliases: []string{"addr"},
@@ -272,8 +525,7 @@ func main() {
 				},
 				cli.Uint64Flag{
 					Name:  "gas-limit",
-					Usage: "Gas limit (multiplied by price for total gas)",
-					Value: 21000,
+					Usage: "Gas limit (multiplied by price for total gas). Estimated if omitted.",
 				},
 				cli.StringFlag{
 					Name:  "gas-price",
@@ -283,6 +535,14 @@ func main() {
 					Name:  "gas-price-gwei",
 					Usage: "Gas price to use in GWEI, if left blank, will use suggested gas price.",
 				},
+				cli.StringFlag{
+					Name:  "max-fee",
+					Usage: "Max fee per gas in GWEI for EIP-1559 transactions, if left blank, will use twice the base fee plus the priority fee.",
+				},
+				cli.StringFlag{
+					Name:  "priority-fee",
+					Usage: "Max priority fee (tip) per gas in GWEI for EIP-1559 transactions, if left blank, will use suggested priority fee.",
+				},
 				cli.StringFlag{
 					Name:  "data",
 					Usage: "Data for smart contract call in hex (can copy from etherscan and other explorers)",
@@ -304,13 +564,13 @@ func main() {
 				} else {
 					amount = nil
 				}
-				price, limit := parseGasPriceAndLimit(c)
+				opts := parseTxOpts(c)
 				to := common.HexToAddress(toS)
 				dataB, err := hex.DecodeString(strings.TrimPrefix(c.String("data"), "0x"))
 				if err != nil {
 					fatalExit(err)
 				}
-				ReplaceTx(ctx, privateKey, network, c.Uint64("nonce"), to, amount, price, limit, dataB)
+				ReplaceTx(ctx, privateKey, network, c.Uint64("nonce"), to, amount, opts, dataB)
 			},
 		},
 		{
@@ -392,10 +652,14 @@ func main() {
 						for i, v := range c.Args().Tail() {
 							args[i] = v
 						}
-						price, limit := parseGasPriceAndLimit(c)
+						opts := parseTxOpts(c)
+						proxyKind := c.String("proxy-kind")
+						if proxyKind == "" && upgradeable {
+							proxyKind = "owner"
+						}
 						DeploySol(ctx, network, privateKey, binFile, c.String("verify"),
 							c.String("solc-version"), c.String("evm-version"), c.BoolT("optimize"),
-							c.String("explorer-api"), price, limit, upgradeable, c.Uint64("timeout"), args...)
+							c.String("explorer-api"), opts, proxyKind, c.String("initializer"), c.Uint64("timeout"), args...)
 					},
 					Flags: []cli.Flag{
 						cli.StringFlag{
@@ -406,9 +670,18 @@ func main() {
 							Hidden:      false},
 						cli.BoolFlag{
 							Name:        "upgradeable",
-							Usage:       "Allow contract to be upgraded",
+							Usage:       "Allow contract to be upgraded, by deploying it behind an owner upgradeable proxy",
 							Destination: &upgradeable,
 							Hidden:      false},
+						cli.StringFlag{
+							Name:  "proxy-kind",
+							Usage: "Deploy the contract behind a proxy: transparent (EIP-1967 proxy upgraded by its admin), uups (EIP-1967 proxy upgraded by the implementation) or owner (owner upgradeable proxy)",
+						},
+						cli.StringFlag{
+							Name:  "initializer",
+							Usage: "Function called through a transparent or uups proxy with the contract args, in place of the constructor. Set it to \"\" to deploy the proxy uninitialized",
+							Value: "initialize",
+						},
 						cli.StringFlag{
 							Name:  "verify",
 							Usage: "Source code of the contract",
@@ -431,7 +704,7 @@ func main() {
 						},
 						cli.Uint64Flag{
 							Name:  "gas-limit",
-							Value: 4000000,
+							Usage: "Gas limit (multiplied by price for total gas). Estimated if omitted.",
 						},
 						cli.StringFlag{
 							Name:  "gas-price",
@@ -441,6 +714,14 @@ func main() {
 							Name:  "gas-price-gwei",
 							Usage: "Gas price to use in GWEI, if left blank, will use suggested gas price.",
 						},
+						cli.StringFlag{
+							Name:  "max-fee",
+							Usage: "Max fee per gas in GWEI for EIP-1559 transactions, if left blank, will use twice the base fee plus the priority fee.",
+						},
+						cli.StringFlag{
+							Name:  "priority-fee",
+							Usage: "Max priority fee (tip) per gas in GWEI for EIP-1559 transactions, if left blank, will use suggested priority fee.",
+						},
 						cli.UintFlag{
 							Name:  "timeout",
 							Usage: "Timeout in seconds (default: 60).",
@@ -504,7 +785,7 @@ func main() {
 							args[i] = v
 						}
 						amount := toAmountBig(c.String("amount"))
-						price, limit := parseGasPriceAndLimit(c)
+						opts := parseTxOpts(c)
 						client, err := web3.Dial(network.URL)
 						if err != nil {
 							fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
@@ -518,7 +799,27 @@ func main() {
 								fatalExit(err)
 							}
 						}
-						callContract(ctx, client, privateKey, contractAddress, abiFile, function, amount, price, limit, waitForReceipt, c.Bool("to-string"), dataB, c.Uint64("timeout"), args...)
+						block := parseBlockNumberOrHash(c.String("block"))
+						var overrides web3.StateOverride
+						if f := c.String("state-override"); f != "" {
+							overrides = readStateOverride(f)
+						}
+						contractABI := abiFile
+						if c.Bool("proxy") {
+							proxy := resolveProxy(ctx, client, contractAddress)
+							if verbose {
+								log.Printf("Calling implementation %s through %s proxy", proxy.Implementation.Hex(), proxy.Kind)
+							}
+							if contractABI == "" && len(dataB) == 0 {
+								explorerURL := c.String("explorer-api")
+								if explorerURL == "" {
+									explorerURL = network.ExplorerURL
+								}
+								contractABI = getVerifiedABI(ctx, explorerURL, proxy.Implementation)
+							}
+						}
+						callContract(ctx, client, privateKey, contractAddress, contractABI, function, amount, opts, waitForReceipt, c.Bool("to-string"),
+							block, overrides, dataB, c.Uint64("timeout"), args...)
 					},
 					Flags: []cli.Flag{
 						cli.StringFlag{
@@ -537,6 +838,14 @@ func main() {
 							Destination: &abiFile,
 							Usage:       "ABI file matching deployed contract",
 							Hidden:      false},
+						cli.BoolFlag{
+							Name:  "proxy",
+							Usage: "Call the implementation of the proxy at --address. Without --abi, the implementation's verified ABI is fetched from the explorer API",
+						},
+						cli.StringFlag{
+							Name:  "explorer-api",
+							Usage: "Etherscan compatible explorer API URL, to fetch verified ABIs from. Default: the network's explorer",
+						},
 						cli.StringFlag{
 							Name:   "amount",
 							Usage:  "Amount in wei that you want to send to the transaction",
@@ -558,7 +867,7 @@ func main() {
 						},
 						cli.Uint64Flag{
 							Name:  "gas-limit",
-							Value: 70000,
+							Usage: "Gas limit (multiplied by price for total gas). Estimated if omitted.",
 						},
 						cli.StringFlag{
 							Name:  "gas-price",
@@ -568,6 +877,14 @@ func main() {
 							Name:  "gas-price-gwei",
 							Usage: "Gas price to use in GWEI, if left blank, will use suggested gas price.",
 						},
+						cli.StringFlag{
+							Name:  "max-fee",
+							Usage: "Max fee per gas in GWEI for EIP-1559 transactions, if left blank, will use twice the base fee plus the priority fee.",
+						},
+						cli.StringFlag{
+							Name:  "priority-fee",
+							Usage: "Max priority fee (tip) per gas in GWEI for EIP-1559 transactions, if left blank, will use suggested priority fee.",
+						},
 						cli.StringFlag{
 							Name:  "data",
 							Usage: "Data for smart contract call in hex (can copy from etherscan and other explorers)",
@@ -577,6 +894,14 @@ func main() {
 							Usage: "Timeout in seconds (default: 60).",
 							Value: 60,
 						},
+						cli.StringFlag{
+							Name:  "block",
+							Usage: "Block number (decimal integer) or hash to call constant functions against. Default: latest",
+						},
+						cli.StringFlag{
+							Name:  "state-override",
+							Usage: "JSON file of per-address state overrides (balance, nonce, code, state, stateDiff) for constant function calls",
+						},
 					},
 				},
 				{
@@ -584,7 +909,8 @@ func main() {
 					Usage: "Upgrade contract to new address",
 					Action: func(c *cli.Context) {
 						amount := toAmountBig(c.String("amount"))
-						UpgradeContract(ctx, network.URL, network.ChainID, privateKey, contractAddress, toContractAddress, amount, c.Uint64("timeout"))
+						UpgradeContract(ctx, network.URL, network.ChainID, privateKey, contractAddress, toContractAddress, amount, c.Uint64("timeout"),
+							c.String("old-abi"), c.String("new-abi"), c.String("old-layout"), c.String("new-layout"), c.Bool("force"))
 					},
 					Flags: []cli.Flag{
 						cli.StringFlag{
@@ -598,6 +924,26 @@ func main() {
 							Destination: &toContractAddress,
 							Usage:       "Contract address to upgrade to",
 							Hidden:      false},
+						cli.StringFlag{
+							Name:  "old-abi",
+							Usage: "ABI of the current target, requiring --new-abi. The upgrade is aborted if the ABIs differ in breaking ways",
+						},
+						cli.StringFlag{
+							Name:  "new-abi",
+							Usage: "ABI of the contract to upgrade to",
+						},
+						cli.StringFlag{
+							Name:  "old-layout",
+							Usage: "Storage layout of the current target, as written by `contract build`, requiring --new-layout. The upgrade is aborted if the storage layouts are incompatible",
+						},
+						cli.StringFlag{
+							Name:  "new-layout",
+							Usage: "Storage layout of the contract to upgrade to",
+						},
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "Upgrade even if the ABIs or storage layouts are incompatible",
+						},
 						cli.StringFlag{
 							Name:   "amount",
 							Usage:  "Amount in wei that you want to send to the transaction",
@@ -615,9 +961,17 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:      "inspect",
+					Usage:     "Show the standards implemented by a contract, its code size, proxy status and compiler. eg: `web3 contract inspect 0xCONTRACT_ADDRESS`",
+					ArgsUsage: "ADDRESS",
+					Action: func(c *cli.Context) {
+						InspectContract(ctx, network.URL, c.Args().First())
+					},
+				},
 				{
 					Name:  "target",
-					Usage: "Return target address of upgradeable proxy",
+					Usage: "Return the implementation address of a proxy (EIP-1967, EIP-1822, EIP-1167 or upgradeable proxy)",
 					Action: func(c *cli.Context) {
 						GetTargetContract(ctx, network.URL, contractAddress)
 					},
@@ -632,7 +986,7 @@ func main() {
 				},
 				{
 					Name:  "pause",
-					Usage: "Pause an upgradeable contract",
+					Usage: "Pause an upgradeable contract. Transparent proxies can't be paused, since their ProxyAdmin can only upgrade them",
 					Action: func(c *cli.Context) {
 						address := c.Args().First()
 						if address == "" {
@@ -667,7 +1021,7 @@ func main() {
 				},
 				{
 					Name:  "resume",
-					Usage: "Resume a paused upgradeable contract",
+					Usage: "Resume a paused upgradeable contract. Transparent proxies can't be resumed, since their ProxyAdmin can only upgrade them",
 					Action: func(c *cli.Context) {
 						address := c.Args().First()
 						if address == "" {
@@ -751,16 +1105,12 @@ func main() {
 				},
 			},
 			Action: func(c *cli.Context) {
-				pk := c.String("private-key")
-				if pk == "" {
+				addr := signerAddress(c.String("private-key"))
+				if addr == "" {
 					fmt.Printf("%v not set", pkVarName)
 					return
 				}
-				acc, err := web3.ParsePrivateKey(pk)
-				if err != nil {
-					fatalExit(err)
-				}
-				fmt.Println(acc.PublicKey())
+				fmt.Println(addr)
 			},
 		},
 		{
@@ -770,8 +1120,42 @@ func main() {
 			Subcommands: []cli.Command{
 				{
 					Name:  "create",
-					Usage: "Create a new account",
+					Usage: "Create a new account, or a new mnemonic and its first account with --mnemonic",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "mnemonic",
+							Usage: "Create a BIP-39 mnemonic and derive the account from it",
+						},
+						cli.IntFlag{
+							Name:  "words",
+							Usage: "Number of mnemonic words: 12, 15, 18, 21 or 24",
+							Value: 12,
+						},
+						cli.StringFlag{
+							Name:   "passphrase",
+							Usage:  "Optional BIP-39 passphrase",
+							EnvVar: passphraseVarName,
+						},
+						cli.BoolFlag{
+							Name:  "show-private-key",
+							Usage: "Also print the private key",
+						},
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory to import the account into",
+						},
+						cli.StringFlag{
+							Name:   "password-file",
+							Usage:  "File holding the keystore password. Prompted for if omitted",
+							EnvVar: passwordFileVarName,
+						},
+					},
 					Action: func(c *cli.Context) {
+						if c.Bool("mnemonic") {
+							setKeystoreFlags(c)
+							AccountCreateMnemonic(c.Int("words"), c.String("passphrase"), c.Bool("show-private-key"), c.String("keystore"))
+							return
+						}
 						acc, err := web3.CreateAccount()
 						if err != nil {
 							fatalExit(err)
@@ -780,6 +1164,53 @@ func main() {
 						fmt.Printf("Public address: %v\n", acc.PublicKey())
 					},
 				},
+				{
+					Name:  "derive",
+					Usage: "Derive accounts from a BIP-39 mnemonic at m/44'/60'/0'/0/index. eg: `web3 account derive --index 3 --count 10`",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "mnemonic",
+							Usage:  "BIP-39 mnemonic. Prompted for if omitted",
+							EnvVar: mnemonicVarName,
+						},
+						cli.StringFlag{
+							Name:   "passphrase",
+							Usage:  "Optional BIP-39 passphrase",
+							EnvVar: passphraseVarName,
+						},
+						cli.UintFlag{
+							Name:  "index",
+							Usage: "Index of the first account",
+						},
+						cli.UintFlag{
+							Name:  "count",
+							Usage: "Number of accounts to derive",
+							Value: 1,
+						},
+						cli.StringFlag{
+							Name:  "path",
+							Usage: "Full derivation path, eg: m/44'/6060'/0'/0/0, instead of --index",
+						},
+						cli.BoolFlag{
+							Name:  "show-private-key",
+							Usage: "Also print the private keys",
+						},
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory to import the accounts into",
+						},
+						cli.StringFlag{
+							Name:   "password-file",
+							Usage:  "File holding the keystore password. Prompted for if omitted",
+							EnvVar: passwordFileVarName,
+						},
+					},
+					Action: func(c *cli.Context) {
+						setKeystoreFlags(c)
+						AccountDerive(c.String("mnemonic"), c.String("passphrase"), c.String("path"), uint32(c.Uint("index")), uint32(c.Uint("count")),
+							c.Bool("show-private-key"), c.String("keystore"))
+					},
+				},
 				{
 					Name:  "extract",
 					Usage: "Extract private key from keystore file",
@@ -809,6 +1240,94 @@ func main() {
 						fmt.Printf("Public address: %v\n", key.Address.Hex())
 					},
 				},
+				{
+					Name:  "new",
+					Usage: "Create a new account in an encrypted keystore. eg: `web3 account new --keystore ~/.web3/keystore`",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "keystore",
+							Usage:  "Keystore directory",
+							EnvVar: keystoreVarName,
+						},
+						cli.StringFlag{
+							Name:   "password-file",
+							Usage:  "File holding the keystore password. Prompted for if omitted",
+							EnvVar: passwordFileVarName,
+						},
+					},
+					Action: func(c *cli.Context) {
+						setKeystoreFlags(c)
+						AccountNew(keystoreDir)
+					},
+				},
+				{
+					Name:  "import",
+					Usage: "Import a private key into an encrypted keystore. The key is prompted for, or read from the first line of stdin, followed by the password unless --password-file is set",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "keystore",
+							Usage:  "Keystore directory",
+							EnvVar: keystoreVarName,
+						},
+						cli.StringFlag{
+							Name:   "password-file",
+							Usage:  "File holding the keystore password. Prompted for if omitted",
+							EnvVar: passwordFileVarName,
+						},
+					},
+					Action: func(c *cli.Context) {
+						setKeystoreFlags(c)
+						AccountImport(keystoreDir)
+					},
+				},
+				{
+					Name:  "export",
+					Usage: "Export an account from a keystore as a keystore file encrypted with a new password",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "keystore",
+							Usage:  "Keystore directory",
+							EnvVar: keystoreVarName,
+						},
+						cli.StringFlag{
+							Name:   "password-file",
+							Usage:  "File holding the keystore password. Prompted for if omitted",
+							EnvVar: passwordFileVarName,
+						},
+						cli.StringFlag{
+							Name:   "from",
+							Usage:  "Address of the account. Optional if the keystore holds a single account",
+							EnvVar: fromVarName,
+						},
+						cli.StringFlag{
+							Name:  "new-password-file",
+							Usage: "File holding the new password. Prompted for if omitted",
+						},
+						cli.StringFlag{
+							Name:  "out",
+							Usage: "File to write the keystore file to. Default: stdout",
+						},
+					},
+					Action: func(c *cli.Context) {
+						setKeystoreFlags(c)
+						AccountExport(keystoreDir, fromAddress, c.String("new-password-file"), c.String("out"))
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List the accounts in a keystore",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "keystore",
+							Usage:  "Keystore directory",
+							EnvVar: keystoreVarName,
+						},
+					},
+					Action: func(c *cli.Context) {
+						setKeystoreFlags(c)
+						AccountList(keystoreDir)
+					},
+				},
 			},
 		},
 		{
@@ -843,8 +1362,7 @@ func main() {
 				},
 				cli.Uint64Flag{
 					Name:  "gas-limit",
-					Usage: "Gas limit (multiplied by price for total gas)",
-					Value: 21000,
+					Usage: "Gas limit (multiplied by price for total gas). Estimated if omitted.",
 				},
 				cli.StringFlag{
 					Name:  "gas-price",
@@ -854,6 +1372,14 @@ func main() {
 					Name:  "gas-price-gwei",
 					Usage: "Gas price to use in GWEI, if left blank, will use suggested gas price.",
 				},
+				cli.StringFlag{
+					Name:  "max-fee",
+					Usage: "Max fee per gas in GWEI for EIP-1559 transactions, if left blank, will use twice the base fee plus the priority fee.",
+				},
+				cli.StringFlag{
+					Name:  "priority-fee",
+					Usage: "Max priority fee (tip) per gas in GWEI for EIP-1559 transactions, if left blank, will use suggested priority fee.",
+				},
 			},
 			Action: func(c *cli.Context) {
 				contractAddress = ""
@@ -863,8 +1389,134 @@ func main() {
 						fatalExit(errors.New("You must set ERC20 contract address"))
 					}
 				}
-				price, limit := parseGasPriceAndLimit(c)
-				Transfer(ctx, network.URL, network.ChainID, privateKey, contractAddress, price, limit, c.Bool("wait"), c.Bool("to-string"), c.Uint64("timeout"), c.Args())
+				opts := parseTxOpts(c)
+				Transfer(ctx, network.URL, network.ChainID, privateKey, contractAddress, opts, c.Bool("wait"), c.Bool("to-string"), c.Uint64("timeout"), c.Args())
+			},
+		},
+		{
+			Name:  "erc1155",
+			Usage: "ERC1155 multi token operations",
+			Subcommands: []cli.Command{
+				{
+					Name:      "balance",
+					Usage:     "Balances of accounts for token ids. eg: `web3 erc1155 balance --address 0xTOKEN 0xACCOUNT 1 0xACCOUNT 2`",
+					ArgsUsage: "ACCOUNT ID [ACCOUNT ID...]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "address",
+							EnvVar: addrVarName,
+							Usage:  "Token contract address",
+						},
+						cli.StringFlag{
+							Name:  "block",
+							Usage: "Block number",
+						},
+					},
+					Action: func(c *cli.Context) {
+						ERC1155Balances(ctx, network.URL, c.String("address"), c.String("block"), c.Args())
+					},
+				},
+				{
+					Name:      "transfer",
+					Usage:     "Transfer an amount of a token id to another account. eg: `web3 erc1155 transfer --address 0xTOKEN 1 10 to 0xADDRESS`",
+					ArgsUsage: "ID AMOUNT to ADDRESS",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:        "private-key, pk",
+							Usage:       "Private key",
+							EnvVar:      pkVarName,
+							Destination: &privateKey,
+						},
+						cli.StringFlag{
+							Name:   "address",
+							EnvVar: addrVarName,
+							Usage:  "Token contract address",
+						},
+						cli.StringFlag{
+							Name:  "data",
+							Usage: "Hex data passed to the recipient contract",
+							Value: "0x",
+						},
+						cli.BoolFlag{
+							Name:  "wait",
+							Usage: "Wait for the receipt of this transaction",
+						},
+						cli.UintFlag{
+							Name:  "timeout",
+							Usage: "Timeout in seconds (default: 60).",
+							Value: 60,
+						},
+						cli.Uint64Flag{
+							Name:  "gas-limit",
+							Usage: "Gas limit (multiplied by price for total gas). Estimated if omitted.",
+						},
+						cli.StringFlag{
+							Name:  "gas-price",
+							Usage: "Gas price to use, if left blank, will use suggested gas price.",
+						},
+						cli.StringFlag{
+							Name:  "gas-price-gwei",
+							Usage: "Gas price to use in GWEI, if left blank, will use suggested gas price.",
+						},
+						cli.StringFlag{
+							Name:  "max-fee",
+							Usage: "Max fee per gas in GWEI for EIP-1559 transactions, if left blank, will use twice the base fee plus the priority fee.",
+						},
+						cli.StringFlag{
+							Name:  "priority-fee",
+							Usage: "Max priority fee (tip) per gas in GWEI for EIP-1559 transactions, if left blank, will use suggested priority fee.",
+						},
+					},
+					Action: func(c *cli.Context) {
+						opts := parseTxOpts(c)
+						ERC1155Transfer(ctx, network.URL, network.ChainID, privateKey, c.String("address"), c.String("data"),
+							opts, c.Bool("wait"), c.Uint64("timeout"), c.Args())
+					},
+				},
+			},
+		},
+		{
+			Name:  "vault",
+			Usage: "ERC4626 tokenized vault operations",
+			Subcommands: []cli.Command{
+				{
+					Name:      "preview-deposit",
+					Usage:     "Shares minted for depositing an amount of the vault's asset. eg: `web3 vault preview-deposit --address 0xVAULT 1.5`",
+					ArgsUsage: "ASSETS",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "address",
+							EnvVar: addrVarName,
+							Usage:  "Vault contract address",
+						},
+						cli.StringFlag{
+							Name:  "block",
+							Usage: "Block number",
+						},
+					},
+					Action: func(c *cli.Context) {
+						VaultPreview(ctx, network.URL, c.String("address"), "previewDeposit", c.Args().First(), c.String("block"))
+					},
+				},
+				{
+					Name:      "preview-redeem",
+					Usage:     "Assets returned for redeeming an amount of the vault's shares. eg: `web3 vault preview-redeem --address 0xVAULT 1.5`",
+					ArgsUsage: "SHARES",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "address",
+							EnvVar: addrVarName,
+							Usage:  "Vault contract address",
+						},
+						cli.StringFlag{
+							Name:  "block",
+							Usage: "Block number",
+						},
+					},
+					Action: func(c *cli.Context) {
+						VaultPreview(ctx, network.URL, c.String("address"), "previewRedeem", c.Args().First(), c.String("block"))
+					},
+				},
 			},
 		},
 		{
@@ -1047,7 +1699,7 @@ func main() {
 						},
 					},
 					Action: func(c *cli.Context) {
-						CreateDID(ctx, network.URL, network.ChainID, privateKey, c.Args().First(), c.String("registry"), c.Uint64("timeout"))
+						CreateDID(ctx, network.URL, network.ChainID, getSigner(privateKey), c.Args().First(), c.String("registry"), c.Uint64("timeout"))
 					},
 				},
 				{
@@ -1149,7 +1801,7 @@ func main() {
 						},
 					},
 					Action: func(c *cli.Context) {
-						SignClaim(ctx, network.URL, privateKey, c.String("id"), c.String("type"), c.String("issuer"), c.String("subject"), c.String("data"))
+						SignClaim(ctx, network.URL, getSigner(privateKey), c.String("id"), c.String("type"), c.String("issuer"), c.String("subject"), c.String("data"))
 					},
 				},
 				{
@@ -1195,6 +1847,39 @@ func toAmountBig(a string) *big.Int {
 	return amount
 }
 
+// parseBlockNumberOrHash parses a block number (decimal integer) or hash, or quits if it is invalid.
+// The empty string or "latest" refer to the latest block.
+func parseBlockNumberOrHash(s string) web3.BlockNumberOrHash {
+	switch {
+	case s == "" || s == "latest":
+		return web3.BlockNumberOrHash{}
+	case strings.HasPrefix(s, "0x"):
+		b, err := hexutil.Decode(s)
+		if err != nil || len(b) != common.HashLength {
+			fatalExit(fmt.Errorf("Invalid block hash %q", s))
+		}
+		return web3.BlockHash(common.BytesToHash(b))
+	}
+	n, err := web3.ParseBigInt(s)
+	if err != nil {
+		fatalExit(fmt.Errorf("Block argument must be a number (decimal integer) or hash %q: %v", s, err))
+	}
+	return web3.BlockNumber(n)
+}
+
+// readStateOverride reads a JSON state override set from file, or quits if it is invalid.
+func readStateOverride(file string) web3.StateOverride {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to read file %q: %v", file, err))
+	}
+	var overrides web3.StateOverride
+	if err := json.Unmarshal(b, &overrides); err != nil {
+		fatalExit(fmt.Errorf("Invalid state override file %q: %v", file, err))
+	}
+	return overrides
+}
+
 // getNetwork resolves the rpcUrl from the user specified options, or quits if an illegal combination or value is found.
 func getNetwork(name, rpcURL string, testnet bool) web3.Network {
 	var network web3.Network
@@ -1231,34 +1916,58 @@ func getNetwork(name, rpcURL string, testnet bool) web3.Network {
 	return network
 }
 
-func parseGasPriceAndLimit(c *cli.Context) (*big.Int, uint64) {
-	gasLimit := c.Uint64("gas-limit")
+// parseTxOpts parses the gas and fee flags, or quits if they are invalid.
+func parseTxOpts(c *cli.Context) web3.TxOpts {
+	opts := web3.TxOpts{GasLimit: c.Uint64("gas-limit")}
 	gp := c.String("gas-price")
-	var price *big.Int
 	var ok bool
 	if gp != "" {
-		price, ok = new(big.Int).SetString(gp, 10)
+		opts.GasPrice, ok = new(big.Int).SetString(gp, 10)
 		if !ok {
 			fatalExit(fmt.Errorf("invalid price %v", gp))
 		}
 	}
 	gp = c.String("gas-price-gwei")
 	if gp != "" {
-		price, ok = new(big.Int).SetString(gp, 10)
+		price, ok := new(big.Int).SetString(gp, 10)
 		if !ok {
 			fatalExit(fmt.Errorf("invalid price %v", gp))
 		}
-		price = web3.Gwei(price.Int64())
+		opts.GasPrice = web3.Gwei(price.Int64())
+	}
+	if fee := c.String("max-fee"); fee != "" {
+		var err error
+		opts.MaxFeePerGas, err = web3.ParseGwei(fee)
+		if err != nil {
+			fatalExit(fmt.Errorf("invalid max fee %v: %v", fee, err))
+		}
+	}
+	if fee := c.String("priority-fee"); fee != "" {
+		var err error
+		opts.MaxPriorityFeePerGas, err = web3.ParseGwei(fee)
+		if err != nil {
+			fatalExit(fmt.Errorf("invalid priority fee %v: %v", fee, err))
+		}
 	}
-	return price, gasLimit
+	if opts.GasPrice != nil && (opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil) {
+		fatalExit(errors.New("gas price cannot be combined with max fee or priority fee"))
+	}
+	return opts
 }
 
-func GetBlockDetails(ctx context.Context, network web3.Network, numberOrHash string, txFormat, txInputFormat string) {
+func GetBlockDetails(ctx context.Context, network web3.Network, numberOrHash string, txFormat, txInputFormat, abiFile string) {
 	client, err := web3.Dial(network.URL)
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
 	}
 	defer client.Close()
+	var myabi *abi.ABI
+	if abiFile != "" {
+		myabi, err = web3.GetABI(abiFile)
+		if err != nil {
+			fatalExit(err)
+		}
+	}
 	var block *web3.Block
 	var includeTxs bool
 	switch txFormat {
@@ -1291,6 +2000,9 @@ func GetBlockDetails(ctx context.Context, network web3.Network, numberOrHash str
 	if verbose {
 		log.Println("Block details:")
 	}
+	for _, tx := range block.TxDetails {
+		tx.DecodedInput, _ = decodeInput(tx, myabi)
+	}
 	switch format {
 	case "json":
 		fmt.Println(marshalJSON(block))
@@ -1303,6 +2015,9 @@ func GetBlockDetails(ctx context.Context, network web3.Network, numberOrHash str
 	gasPct := big.NewRat(int64(block.GasUsed), int64(block.GasLimit))
 	gasPct = gasPct.Mul(gasPct, big.NewRat(100, 1))
 	fmt.Printf("Gas Used: %d/%d (%s%%)\n", block.GasUsed, block.GasLimit, gasPct.FloatString(2))
+	if block.BaseFeePerGas != nil {
+		fmt.Println("Base Fee:", web3.WeiAsGwei(block.BaseFeePerGas), "gwei")
+	}
 	fmt.Println("Difficulty:", block.Difficulty)
 	fmt.Println("Total Difficulty:", block.TotalDifficulty)
 	fmt.Println("Hash:", block.Hash.String())
@@ -1325,6 +2040,9 @@ func GetBlockDetails(ctx context.Context, network web3.Network, numberOrHash str
 	if len(block.Signer) > 0 {
 		fmt.Println("Signer:", "0x"+common.Bytes2Hex(block.Signer))
 	}
+	if block.Withdrawals != nil {
+		fmt.Println("Withdrawals:", len(block.Withdrawals))
+	}
 	if block.TxCount() > 0 {
 		switch txFormat {
 		case "hash":
@@ -1338,13 +2056,22 @@ func GetBlockDetails(ctx context.Context, network web3.Network, numberOrHash str
 				fmt.Printf("\t%d\t", i)
 				fmt.Print("Hash: ", tx.Hash.Hex())
 				fmt.Print(" From: ", tx.From.Hex())
-				fmt.Print(" To: ", tx.To.Hex())
+				if tx.To != nil {
+					fmt.Print(" To: ", tx.To.Hex())
+				}
 				fmt.Print(" Value: ", web3.WeiAsBase(tx.Value), " ", network.Unit)
 				fmt.Print(" Nonce: ", tx.Nonce)
 				fmt.Print(" Gas Limit: ", tx.GasLimit)
 				fmt.Print(" Gas Price: ", web3.WeiAsGwei(tx.GasPrice), " gwei")
+				if tx.MaxFeePerGas != nil {
+					fmt.Print(" Max Fee: ", web3.WeiAsGwei(tx.MaxFeePerGas), " gwei")
+					fmt.Print(" Priority Fee: ", web3.WeiAsGwei(tx.MaxPriorityFeePerGas), " gwei")
+				}
 				fmt.Print(" ")
 				printInputData(tx.Input, txInputFormat)
+				if tx.DecodedInput != nil {
+					fmt.Print(" Call: ", tx.DecodedInput)
+				}
 				fmt.Println()
 			}
 		}
@@ -1366,7 +2093,7 @@ func (fa fmtAddresses) String() string {
 	return b.String()
 }
 
-func GetTransactionDetails(ctx context.Context, network web3.Network, txhash, inputFormat string) {
+func GetTransactionDetails(ctx context.Context, network web3.Network, txhash, inputFormat, abiFile string) {
 	client, err := web3.Dial(network.URL)
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
@@ -1380,6 +2107,16 @@ func GetTransactionDetails(ctx context.Context, network web3.Network, txhash, in
 	if verbose {
 		fmt.Println("Transaction details:")
 	}
+	var decodeErr error
+	if abiFile != "" {
+		myabi, err := web3.GetABI(abiFile)
+		if err != nil {
+			fatalExit(err)
+		}
+		tx.DecodedInput, decodeErr = decodeInput(tx, myabi)
+	} else {
+		tx.DecodedInput, _ = decodeInput(tx, nil)
+	}
 
 	switch format {
 	case "json":
@@ -1394,8 +2131,17 @@ func GetTransactionDetails(ctx context.Context, network web3.Network, txhash, in
 	}
 	fmt.Println("Value:", web3.WeiAsBase(tx.Value), network.Unit)
 	fmt.Println("Nonce:", uint64(tx.Nonce))
+	fmt.Println("Type:", tx.Type)
 	fmt.Println("Gas Limit:", tx.GasLimit)
-	fmt.Println("Gas Price:", web3.WeiAsGwei(tx.GasPrice), "gwei")
+	if tx.GasPrice != nil {
+		fmt.Println("Gas Price:", web3.WeiAsGwei(tx.GasPrice), "gwei")
+	}
+	if tx.MaxFeePerGas != nil {
+		fmt.Println("Max Fee:", web3.WeiAsGwei(tx.MaxFeePerGas), "gwei")
+	}
+	if tx.MaxPriorityFeePerGas != nil {
+		fmt.Println("Priority Fee:", web3.WeiAsGwei(tx.MaxPriorityFeePerGas), "gwei")
+	}
 	if tx.BlockHash == (common.Hash{}) {
 		fmt.Println("Pending: true")
 	} else {
@@ -1404,6 +2150,48 @@ func GetTransactionDetails(ctx context.Context, network web3.Network, txhash, in
 	}
 	printInputData(tx.Input, inputFormat)
 	fmt.Println()
+	if tx.DecodedInput != nil {
+		printCallData(tx.DecodedInput)
+	} else if decodeErr != nil {
+		fmt.Println("Cannot decode input:", decodeErr)
+	}
+}
+
+// decodeInput decodes the input of tx as a function call, or as constructor arguments
+// if tx is a contract creation. Without an ABI, calls are decoded with known signatures.
+func decodeInput(tx *web3.Transaction, myabi *abi.ABI) (*web3.CallData, error) {
+	if myabi == nil {
+		if tx.To == nil || len(tx.Input) == 0 {
+			return nil, nil
+		}
+		return signatureDB().DecodeCallData(tx.Input)
+	}
+	if tx.To == nil {
+		return web3.DecodeConstructorArgs(*myabi, tx.Input)
+	}
+	return web3.DecodeCallData(*myabi, tx.Input)
+}
+
+func printCallData(cd *web3.CallData) {
+	fmt.Println("Method:", cd.Sig)
+	if len(cd.Args) > 0 {
+		fmt.Println("Arguments:")
+	}
+	printCallArgs(cd.Args)
+}
+
+func printCallArgs(args []web3.CallArg) {
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		value := arg.Value
+		if s, ok := value.(fmt.Stringer); ok {
+			value = s.String()
+		}
+		fmt.Printf("\t%s %s: %v\n", arg.Type, name, value)
+	}
 }
 
 func printInputData(data []byte, format string) {
@@ -1423,24 +2211,31 @@ func printInputData(data []byte, format string) {
 func GetAddressDetails(ctx context.Context, network web3.Network, addrHash, privateKey string, onlyBalance bool,
 	contractAddress string, blockNumber string) {
 	if addrHash == "" {
-		if privateKey == "" {
-			fatalExit(errors.New("Missing address. Must be specified as only argument, or implied from a private key."))
+		addrHash = signerAddress(privateKey)
+		if addrHash == "" {
+			fatalExit(errors.New("Missing address. Must be specified as only argument, or implied from a private key or keystore account."))
 		}
-		acct, err := web3.ParsePrivateKey(privateKey)
+	}
+
+	var blockN *big.Int
+	var err error
+	// Don't try to parse empty string, which means 'latest'.
+	if blockNumber != "" {
+		blockN, err = web3.ParseBigInt(blockNumber)
 		if err != nil {
-			fatalExit(err)
+			fatalExit(fmt.Errorf("Block argument must be a number (decimal integer) %q: %v", blockNumber, err))
 		}
-		addrHash = acct.PublicKey()
 	}
 
 	if contractAddress != "" {
-		decimals, err := GetContractConst(ctx, network.URL, contractAddress, "erc20", "decimals")
+		block := web3.BlockNumber(blockN)
+		decimals, err := GetContractConst(ctx, network.URL, contractAddress, "erc20", block, "decimals")
 		if err != nil {
 			fatalExit(err)
 		}
 		// fmt.Println("DECIMALS:", decimals, reflect.TypeOf(decimals))
 		// todo: could get symbol here to display
-		balance, err := GetContractConst(ctx, network.URL, contractAddress, "erc20", "balanceOf", addrHash)
+		balance, err := GetContractConst(ctx, network.URL, contractAddress, "erc20", block, "balanceOf", addrHash)
 		if err != nil {
 			fatalExit(err)
 		}
@@ -1449,16 +2244,6 @@ func GetAddressDetails(ctx context.Context, network web3.Network, addrHash, priv
 		return
 	}
 
-	var blockN *big.Int
-	var err error
-	// Don't try to parse empty string, which means 'latest'.
-	if blockNumber != "" {
-		blockN, err = web3.ParseBigInt(blockNumber)
-		if err != nil {
-			fatalExit(fmt.Errorf("Block argument must be a number (decimal integer) %q: %v", blockNumber, err))
-		}
-	}
-
 	client, err := web3.Dial(network.URL)
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
@@ -1621,7 +2406,7 @@ func BuildSol(ctx context.Context, filename, solcVersion, evmVersion string, opt
 		log.Println("Compiled Sol Details:", marshalJSON(compileData))
 	}
 	fmt.Println("NAME:", name)
-	var filenames []string
+	var filenames, layouts []string
 	for contractName, v := range compileData {
 		fmt.Println("contractName:", contractName)
 		fileparts := strings.Split(contractName, ":")
@@ -1641,17 +2426,26 @@ func BuildSol(ctx context.Context, filename, solcVersion, evmVersion string, opt
 		if err != nil {
 			fatalExit(fmt.Errorf("Cannot write the abi file: %v", err))
 		}
+		if v.Info.StorageLayout != nil {
+			err = ioutil.WriteFile(path+".storage.json", []byte(marshalJSON(v.Info.StorageLayout)), 0600)
+			if err != nil {
+				fatalExit(fmt.Errorf("Cannot write the storage layout file: %v", err))
+			}
+			layouts = append(layouts, fileparts[1]+".storage.json")
+		}
 		filenames = append(filenames, fileparts[1])
 	}
 
 	switch format {
 	case "json":
 		data := struct {
-			Source string   `json:"source"`
-			Bin    []string `json:"bin"`
-			ABI    []string `json:"abi"`
+			Source        string   `json:"source"`
+			Bin           []string `json:"bin"`
+			ABI           []string `json:"abi"`
+			StorageLayout []string `json:"storageLayout,omitempty"`
 		}{}
 		data.Source = sourceFile
+		data.StorageLayout = layouts
 		for _, f := range filenames {
 			data.Bin = append(data.Bin, f+".bin")
 			data.ABI = append(data.ABI, f+".abi")
@@ -1665,6 +2459,9 @@ func BuildSol(ctx context.Context, filename, solcVersion, evmVersion string, opt
 	for _, filename := range filenames {
 		fmt.Println("", filename+".bin,", filename+".abi")
 	}
+	for _, filename := range layouts {
+		fmt.Println("", filename)
+	}
 }
 
 func FlattenSol(ctx context.Context, iFile, oFile string) {
@@ -1685,11 +2482,18 @@ func FlattenSol(ctx context.Context, iFile, oFile string) {
 
 func DeploySol(ctx context.Context, network web3.Network,
 	privateKey, binFile, contractSource, solcVersion, evmVersion string, optimize bool, explorerURL string,
-	gasPrice *big.Int, gasLimit uint64, upgradeable bool, timeoutInSeconds uint64, params ...interface{}) {
+	opts web3.TxOpts, proxyKind, initializer string, timeoutInSeconds uint64, params ...interface{}) {
 
 	if binFile == "" {
 		fatalExit(errors.New("Missing contract name arg."))
 	}
+	switch proxyKind {
+	case "", "owner", "transparent", "uups":
+	default:
+		fatalExit(fmt.Errorf("Unknown proxy kind %q, expected transparent, uups or owner", proxyKind))
+	}
+	// Args are passed to the initializer rather than the constructor of contracts behind EIP-1967 proxies.
+	initialized := proxyKind == "transparent" || proxyKind == "uups"
 	client, err := web3.Dial(network.URL)
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
@@ -1707,7 +2511,7 @@ func DeploySol(ctx context.Context, network web3.Network,
 		fatalExit(fmt.Errorf("Cannot read bin file %q: %v", binFile, err))
 	}
 	var abi string
-	if len(params) > 0 {
+	if len(params) > 0 || (initialized && initializer != "") {
 		abiFile := strings.TrimSuffix(binFile, ".bin") + ".abi"
 		var b []byte
 		if strings.HasPrefix(binFile, "http") {
@@ -1716,15 +2520,27 @@ func DeploySol(ctx context.Context, network web3.Network,
 			b, err = ioutil.ReadFile(abiFile)
 		}
 		if err != nil {
+			if initialized {
+				fatalExit(fmt.Errorf("Cannot read abi file %q to find the initializer: %v. Use --initializer \"\" to deploy the proxy uninitialized", abiFile, err))
+			}
 			fatalExit(fmt.Errorf("Cannot read abi file %q: %v", abiFile, err))
 		}
 		abi = string(b)
 	}
-	tx, err := web3.DeployContract(ctx, client, privateKey, string(bin), abi, gasPrice, gasLimit, params...)
+	constructorArgs := params
+	var initData []byte
+	if initialized {
+		initData = encodeInitializer(abi, initializer, params)
+		constructorArgs = nil
+	}
+	signer := getSigner(privateKey)
+	opts = withNonces(client, signer, opts)
+	tx, err := web3.DeployContractWithSigner(ctx, client, signer, string(bin), abi, opts, constructorArgs...)
 	if err != nil {
 		fatalExit(fmt.Errorf("Error deploying contract: %v", err))
 	}
-	waitCtx, _ := context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(waitCtx, client, tx.Hash)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot get the receipt for transaction with hash '%v': %v", tx.Hash.Hex(), err))
@@ -1741,7 +2557,7 @@ func DeploySol(ctx context.Context, network web3.Network,
 	}
 
 	// Exit early if contract is static.
-	if !upgradeable {
+	if proxyKind == "" {
 		fmt.Println("Contract has been successfully deployed with transaction:", tx.Hash.Hex())
 		fmt.Println("Contract address is:", receipt.ContractAddress.Hex())
 		if contractSource != "" {
@@ -1752,11 +2568,21 @@ func DeploySol(ctx context.Context, network web3.Network,
 	}
 
 	// Deploy proxy contract.
-	proxyTx, err := web3.DeployContract(ctx, client, privateKey, assets.OwnerUpgradeableProxyCode(receipt.ContractAddress), "", gasPrice, gasLimit)
+	var proxyCode string
+	switch proxyKind {
+	case "owner":
+		proxyCode = assets.OwnerUpgradeableProxyCode(receipt.ContractAddress)
+	case "transparent":
+		proxyCode = assets.TransparentProxyCode(receipt.ContractAddress, signer.Address(), initData)
+	case "uups":
+		proxyCode = assets.ERC1967ProxyCode(receipt.ContractAddress, initData)
+	}
+	proxyTx, err := web3.DeployContractWithSigner(ctx, client, signer, proxyCode, "", opts)
 	if err != nil {
 		log.Fatalf("Cannot deploy the upgradeable proxy contract: %v", err)
 	}
-	waitCtx, _ = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	waitCtx, cancel = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	defer cancel()
 	proxyReceipt, err := web3.WaitForReceipt(waitCtx, client, proxyTx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the upgradeable proxy receipt: %v", err)
@@ -1767,10 +2593,39 @@ func DeploySol(ctx context.Context, network web3.Network,
 	}
 
 	fmt.Println("Upgradeable contract has been successfully deployed.")
+	fmt.Println("Implementation address is:", receipt.ContractAddress.Hex())
 	fmt.Println("Contract has been successfully deployed with transaction:", proxyTx.Hash.Hex())
 	fmt.Println("Contract address is:", proxyReceipt.ContractAddress.Hex())
 }
 
+// encodeInitializer returns the call data for the initializer function in abiJSON with params,
+// or nil if initializer is empty, which leaves the proxy uninitialized.
+func encodeInitializer(abiJSON, initializer string, params []interface{}) []byte {
+	if initializer == "" {
+		if len(params) > 0 {
+			fatalExit(errors.New("Cannot pass args to the contract without an initializer"))
+		}
+		return nil
+	}
+	myabi, err := web3.GetABI(abiJSON)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot parse the ABI: %v", err))
+	}
+	fn, ok := myabi.Methods[initializer]
+	if !ok {
+		fatalExit(fmt.Errorf("The ABI has no %q function to initialize the proxy. Use --initializer to choose another function, or --initializer \"\" to deploy the proxy uninitialized", initializer))
+	}
+	goParams, err := web3.ConvertArguments(fn.Inputs, params)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid %s args: %v", initializer, err))
+	}
+	data, err := myabi.Pack(initializer, goParams...)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot pack %s args: %v", initializer, err))
+	}
+	return data
+}
+
 func VerifyContract(ctx context.Context, network web3.Network, explorerURL, contractAddress, contractName,
 	sourceCodeFile, compilerVersion, evmVersion string, optimize bool) {
 	if explorerURL == "" {
@@ -1852,93 +2707,172 @@ func VerifyContract(ctx context.Context, network web3.Network, explorerURL, cont
 	fatalExit(fmt.Errorf("Cannot verify the contract: %s, error code: %v", errResp.Error.Message, resp.StatusCode))
 }
 
-func UpgradeContract(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress, newTargetAddress string, amount *big.Int, timeoutInSeconds uint64) {
-	client, err := web3.Dial(rpcURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
-	}
-	client.SetChainID(chainID)
-	defer client.Close()
-	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
-	if err != nil {
-		log.Fatalf("Cannot initialize ABI: %v", err)
+func UpgradeContract(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress, newTargetAddress string, amount *big.Int, timeoutInSeconds uint64,
+	oldABIFile, newABIFile, oldLayoutFile, newLayoutFile string, force bool) {
+	if (oldABIFile == "") != (newABIFile == "") {
+		fatalExit(errors.New("--old-abi and --new-abi must be given together"))
+	}
+	if oldABIFile != "" {
+		changes := diffABIFiles(oldABIFile, newABIFile)
+		if web3.BreakingChanges(changes) {
+			for _, c := range changes {
+				if c.Breaking {
+					fmt.Println(c)
+				}
+			}
+			if !force {
+				fatalExit(errors.New("The new ABI has breaking changes. Use --force to upgrade anyway"))
+			}
+			fmt.Println("Upgrading despite breaking changes")
+		}
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, privateKey, "upgrade", amount, nil, 100000, newTargetAddress)
-	if err != nil {
-		log.Fatalf("Cannot upgrade the contract: %v", err)
+	if (oldLayoutFile == "") != (newLayoutFile == "") {
+		fatalExit(errors.New("--old-layout and --new-layout must be given together"))
 	}
-	ctx, _ = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
-	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
-	if err != nil {
-		log.Fatalf("Cannot get the receipt for transaction with hash '%v': %v", tx.Hash.Hex(), err)
+	if oldLayoutFile != "" {
+		oldLayout, err := web3.ReadStorageLayout(oldLayoutFile)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read storage layout: %v", err))
+		}
+		newLayout, err := web3.ReadStorageLayout(newLayoutFile)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read storage layout: %v", err))
+		}
+		changes := web3.DiffStorageLayout(oldLayout, newLayout)
+		if web3.BreakingStorageChanges(changes) {
+			for _, c := range changes {
+				if c.Breaking {
+					fmt.Println(c)
+				}
+			}
+			if !force {
+				fatalExit(errors.New("The new storage layout is incompatible with the current one. Use --force to upgrade anyway"))
+			}
+			fmt.Println("Upgrading despite incompatible storage layout")
+		}
 	}
-	fmt.Println("Transaction address:", receipt.TxHash.Hex())
+	transactProxy(ctx, rpcURL, chainID, privateKey, contractAddress, "upgrade", amount, timeoutInSeconds, newTargetAddress)
 }
 
+// GetTargetContract prints the implementation address of a proxy, such as an EIP-1967 or EIP-1167 proxy,
+// or this project's upgradeable proxy.
 func GetTargetContract(ctx context.Context, rpcURL, contractAddress string) {
 	client, err := web3.Dial(rpcURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
 	}
 	defer client.Close()
-	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
-	if err != nil {
-		log.Fatalf("Cannot initialize ABI: %v", err)
-	}
-	res, err := web3.CallConstantFunction(ctx, client, myabi, contractAddress, "target")
-	if err != nil {
-		log.Fatalf("Cannot upgrade the contract: %v", err)
-	}
-	if len(res) != 1 {
-		log.Fatalf("Expected single result but got: %v", res)
-	}
-	switch res := res[0].(type) {
-	case common.Address:
-		fmt.Println(res.String())
-	default:
-		log.Fatalf("Unexpected return: %#v", res)
+	proxy := resolveProxy(ctx, client, contractAddress)
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(proxy))
+		return
 	}
+	fmt.Println(proxy.Implementation.Hex())
 }
 
-func PauseContract(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress string, amount *big.Int, timeoutInSeconds uint64) {
-	client, err := web3.Dial(rpcURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
-	}
-	client.SetChainID(chainID)
-	defer client.Close()
-	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
-	if err != nil {
-		log.Fatalf("Cannot initialize ABI: %v", err)
+// resolveProxy returns the proxy at contractAddress, exiting if it isn't a recognized proxy.
+func resolveProxy(ctx context.Context, client web3.Client, contractAddress string) *web3.Proxy {
+	if !common.IsHexAddress(contractAddress) {
+		fatalExit(fmt.Errorf("Invalid contract address: %q", contractAddress))
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, privateKey, "pause", amount, nil, 70000)
+	proxy, err := web3.ResolveProxy(ctx, client, common.HexToAddress(contractAddress))
 	if err != nil {
-		log.Fatalf("Cannot pause the contract: %v", err)
+		fatalExit(fmt.Errorf("Cannot resolve the proxy: %v", err))
 	}
-	ctx, _ = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
-	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
-	if err != nil {
-		log.Fatalf("Cannot get the receipt for transaction with hash '%v': %v", tx.Hash.Hex(), err)
+	if proxy == nil {
+		fatalExit(fmt.Errorf("%s is not a recognized proxy", contractAddress))
 	}
-	fmt.Println("Transaction address:", receipt.TxHash.Hex())
+	return proxy
+}
+
+func PauseContract(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress string, amount *big.Int, timeoutInSeconds uint64) {
+	transactProxy(ctx, rpcURL, chainID, privateKey, contractAddress, "pause", amount, timeoutInSeconds)
 }
 
 func ResumeContract(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress string, amount *big.Int, timeoutInSeconds uint64) {
+	transactProxy(ctx, rpcURL, chainID, privateKey, contractAddress, "resume", amount, timeoutInSeconds)
+}
+
+// uupsABI holds the upgrade and pause functions of UUPS implementations, such as OpenZeppelin's
+// UUPSUpgradeable and PausableUpgradeable, which are called through their proxy.
+const uupsABI = `function upgradeToAndCall(address newImplementation, bytes data) payable
+function pause()
+function unpause()`
+
+// transparentAdminABI holds the upgrade functions of OpenZeppelin v4 transparent proxies, which are
+// called by their admin account, and of v4 ProxyAdmin contracts, which are called by their owner.
+const transparentAdminABI = `function upgradeTo(address newImplementation)
+function upgrade(address proxy, address implementation)`
+
+// transactProxy performs action (upgrade, pause or resume) on the proxy at contractAddress,
+// calling the function which matches the kind of proxy.
+func transactProxy(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress, action string,
+	amount *big.Int, timeoutInSeconds uint64, params ...interface{}) {
 	client, err := web3.Dial(rpcURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
 	}
 	client.SetChainID(chainID)
 	defer client.Close()
-	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
+	signer := getSigner(privateKey)
+	proxy := resolveProxy(ctx, client, contractAddress)
+	to, abiJSON, functionName := contractAddress, assets.UpgradeableProxyABI, action
+	switch {
+	case proxy.Kind == web3.ProxyOwner || proxy.Kind == web3.ProxyTarget:
+	case proxy.Kind == web3.ProxyEIP1967 && proxy.Admin != nil:
+		if action != "upgrade" {
+			// The proxy passes no call from its admin on to the implementation, and a ProxyAdmin
+			// can only upgrade it, so pause and resume can't be routed through the admin either.
+			fatalExit(fmt.Errorf("Cannot %s a transparent proxy: its ProxyAdmin can only upgrade it. "+
+				"Call the pause functions of the implementation, if it has them, with contract call", action))
+		}
+		if *proxy.Admin == signer.Address() {
+			abiJSON, functionName = transparentAdminABI, "upgradeTo"
+			break
+		}
+		owner, err := web3.ProxyAdminOwner(ctx, client, *proxy.Admin)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot get the owner of the proxy admin %s: %v", proxy.Admin.Hex(), err))
+		}
+		if owner == nil || *owner != signer.Address() {
+			fatalExit(fmt.Errorf("Cannot upgrade the proxy: its admin %s is neither %s nor a ProxyAdmin owned by it",
+				proxy.Admin.Hex(), signer.Address().Hex()))
+		}
+		code, err := client.GetCode(ctx, proxy.Admin.Hex(), nil)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot get the code of the proxy admin %s: %v", proxy.Admin.Hex(), err))
+		}
+		to, params = proxy.Admin.Hex(), append([]interface{}{contractAddress}, params...)
+		if web3.HasSelectors(code, "UPGRADE_INTERFACE_VERSION()") {
+			// OpenZeppelin v5 ProxyAdmin only has upgradeAndCall, which skips the call without data.
+			abiJSON, functionName = assets.ProxyAdminABI, "upgradeAndCall"
+			params = append(params, "0x")
+		} else {
+			abiJSON, functionName = transparentAdminABI, "upgrade"
+		}
+	case proxy.Kind == web3.ProxyEIP1967 || proxy.Kind == web3.ProxyEIP1822:
+		abiJSON = uupsABI
+		switch action {
+		case "upgrade":
+			functionName = "upgradeToAndCall"
+			params = append(params, "0x")
+		case "resume":
+			functionName = "unpause"
+		}
+	default:
+		fatalExit(fmt.Errorf("Cannot %s a %s proxy", action, proxy.Kind))
+	}
+	myabi, err := web3.GetABI(abiJSON)
 	if err != nil {
 		log.Fatalf("Cannot initialize ABI: %v", err)
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, privateKey, "resume", amount, nil, 70000)
+	tx, err := web3.CallFunctionWithSigner(ctx, client, signer, to, amount, withNonces(client, signer, web3.TxOpts{}), *myabi, functionName, params...)
 	if err != nil {
-		log.Fatalf("Cannot resume the contract: %v", err)
+		log.Fatalf("Cannot %s the contract: %v", action, err)
 	}
-	ctx, _ = context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	defer cancel()
 	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
 	if err != nil {
 		log.Fatalf("Cannot get the receipt for transaction with hash '%v': %v", tx.Hash.Hex(), err)
@@ -1954,6 +2888,10 @@ func marshalJSON(data interface{}) string {
 	return string(b)
 }
 
+// exitBreaking is the exit status of commands which find breaking changes, such as `abi diff`,
+// to tell them apart from errors, which exit with status 1.
+const exitBreaking = 2
+
 func fatalExit(err error) {
 	fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 	os.Exit(1)
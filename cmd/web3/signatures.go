@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/web3"
+)
+
+var loadSignaturesOnce sync.Once
+
+// signatureDB returns the default signature database, including any signatures imported by the user.
+func signatureDB() *web3.SignatureDB {
+	db := web3.DefaultSignatures()
+	loadSignaturesOnce.Do(func() {
+		path, err := signaturesFile()
+		if err != nil {
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "WARNING: Cannot open signatures file %q: %v\n", path, err)
+			}
+			return
+		}
+		defer f.Close()
+		if _, err := db.Import(f); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: Cannot read signatures file %q: %v\n", path, err)
+		}
+	})
+	return db
+}
+
+// signaturesFile returns the path of the user's signatures file.
+func signaturesFile() (string, error) {
+	if path := os.Getenv(signaturesVarName); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "web3", "signatures.txt"), nil
+}
+
+// LookupSignature prints the known signatures for a 4 byte function selector or 32 byte event topic.
+func LookupSignature(id string) {
+	b, err := hexutil.Decode(id)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid selector or topic %q: %v", id, err))
+	}
+	var sigs []string
+	switch len(b) {
+	case 4:
+		sigs = signatureDB().Functions(b)
+	case common.HashLength:
+		sigs = signatureDB().Events(common.BytesToHash(b))
+	default:
+		fatalExit(fmt.Errorf("Expected a 4 byte function selector or 32 byte event topic but got %d bytes", len(b)))
+	}
+	switch format {
+	case "json":
+		if sigs == nil {
+			sigs = []string{}
+		}
+		fmt.Println(marshalJSON(sigs))
+		return
+	}
+	if len(sigs) == 0 {
+		fatalExit(fmt.Errorf("No known signature for %s", id))
+	}
+	fmt.Println(strings.Join(sigs, "\n"))
+}
+
+// ImportSignatures adds the signatures in file, a JSON ABI or list of signatures, to the user's signatures file,
+// skipping those it already has.
+func ImportSignatures(file string) {
+	if file == "" {
+		fatalExit(errors.New("Missing file argument"))
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot open %q: %v", file, err))
+	}
+	defer f.Close()
+	imported := web3.NewSignatureDB()
+	if _, err := imported.Import(f); err != nil {
+		fatalExit(fmt.Errorf("Cannot read signatures from %q: %v", file, err))
+	}
+	path, err := signaturesFile()
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot locate signatures file: %v", err))
+	}
+	existing := web3.NewSignatureDB()
+	if ef, err := os.Open(path); err == nil {
+		_, err := existing.Import(ef)
+		ef.Close()
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read signatures file %q: %v", path, err))
+		}
+	} else if !os.IsNotExist(err) {
+		fatalExit(fmt.Errorf("Cannot open signatures file %q: %v", path, err))
+	}
+	added := imported.Subtract(existing)
+	n := added.Len()
+	if n == 0 {
+		fmt.Printf("No new signatures to import into %s\n", path)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fatalExit(fmt.Errorf("Cannot create signatures directory: %v", err))
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot open signatures file %q: %v", path, err))
+	}
+	defer out.Close()
+	if err := added.Export(out); err != nil {
+		fatalExit(fmt.Errorf("Cannot write signatures file %q: %v", path, err))
+	}
+	fmt.Printf("Imported %d new signatures into %s\n", n, path)
+}
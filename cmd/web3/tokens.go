@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/web3"
+	"github.com/shopspring/decimal"
+)
+
+// ERC1155Balances prints the balances of pairs of accounts and token ids, eg: `0xACCOUNT 1 0xACCOUNT 2`,
+// fetched with a single balanceOfBatch call.
+func ERC1155Balances(ctx context.Context, rpcURL, contractAddress, blockNumber string, args []string) {
+	if contractAddress == "" {
+		fatalExit(errors.New("You must set the ERC1155 contract address"))
+	}
+	if len(args) == 0 || len(args)%2 != 0 {
+		fatalExit(errors.New("Invalid arguments. Format is: `erc1155 balance 0xACCOUNT ID [0xACCOUNT ID...]`"))
+	}
+	var accounts, ids []string
+	for i := 0; i < len(args); i += 2 {
+		if !common.IsHexAddress(args[i]) {
+			fatalExit(fmt.Errorf("Invalid account address: %s", args[i]))
+		}
+		accounts = append(accounts, args[i])
+		ids = append(ids, args[i+1])
+	}
+	res, err := GetContractConst(ctx, rpcURL, contractAddress, "erc1155", parseBlock(blockNumber), "balanceOfBatch", accounts, ids)
+	if err != nil {
+		fatalExit(err)
+	}
+	balances, ok := res[0].([]*big.Int)
+	if !ok || len(balances) != len(accounts) {
+		fatalExit(fmt.Errorf("Unexpected balanceOfBatch result: %v", res))
+	}
+	switch format {
+	case "json":
+		type balance struct {
+			Account string   `json:"account"`
+			ID      string   `json:"id"`
+			Balance *big.Int `json:"balance"`
+		}
+		out := make([]balance, len(balances))
+		for i, b := range balances {
+			out[i] = balance{Account: accounts[i], ID: ids[i], Balance: b}
+		}
+		fmt.Println(marshalJSON(out))
+		return
+	}
+	for i, b := range balances {
+		fmt.Println(accounts[i], ids[i], b)
+	}
+}
+
+// ERC1155Transfer sends an amount of a token id from the private key's account with safeTransferFrom,
+// eg: `1 10 to 0xADDRESS`.
+func ERC1155Transfer(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress, data string,
+	opts web3.TxOpts, wait bool, timeoutInSeconds uint64, args []string) {
+	if contractAddress == "" {
+		fatalExit(errors.New("You must set the ERC1155 contract address"))
+	}
+	if len(args) < 4 {
+		fatalExit(errors.New("Invalid arguments. Format is: `erc1155 transfer ID AMOUNT to 0xADDRESS`"))
+	}
+	id, amount, toAddress := args[0], args[1], args[3]
+	if !common.IsHexAddress(toAddress) {
+		fatalExit(fmt.Errorf("Invalid to 'address': %s", toAddress))
+	}
+	from := getSigner(privateKey).Address()
+	client, err := web3.Dial(rpcURL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", rpcURL, err))
+	}
+	client.SetChainID(chainID)
+	defer client.Close()
+	callContract(ctx, client, privateKey, contractAddress, "erc1155", "safeTransferFrom", &big.Int{}, opts, wait, false,
+		web3.BlockNumberOrHash{}, nil, nil, timeoutInSeconds, from.Hex(), toAddress, id, amount, data)
+}
+
+// VaultPreview prints the shares minted by depositing an amount of assets ("previewDeposit"), or the
+// assets returned by redeeming an amount of shares ("previewRedeem"), in an ERC4626 vault.
+// Amounts are in units of the asset or share token, scaled by its decimals.
+func VaultPreview(ctx context.Context, rpcURL, contractAddress, function, amountS, blockNumber string) {
+	if contractAddress == "" {
+		fatalExit(errors.New("You must set the vault contract address"))
+	}
+	amountD, err := decimal.NewFromString(amountS)
+	if err != nil {
+		fatalExit(fmt.Errorf("invalid amount %v", amountS))
+	}
+	block := parseBlock(blockNumber)
+	res, err := GetContractConst(ctx, rpcURL, contractAddress, "erc4626", block, "asset")
+	if err != nil {
+		fatalExit(err)
+	}
+	asset := res[0].(common.Address)
+	assetDecimals := tokenDecimals(ctx, rpcURL, asset.Hex(), block)
+	shareDecimals := tokenDecimals(ctx, rpcURL, contractAddress, block)
+
+	inDecimals, outDecimals := assetDecimals, shareDecimals
+	if function == "previewRedeem" {
+		inDecimals, outDecimals = shareDecimals, assetDecimals
+	}
+	res, err = GetContractConst(ctx, rpcURL, contractAddress, "erc4626", block, function, web3.DecToInt(amountD, inDecimals))
+	if err != nil {
+		fatalExit(err)
+	}
+	out := web3.IntToDec(res[0].(*big.Int), outDecimals)
+	switch format {
+	case "json":
+		assets, shares := amountD, out
+		if function == "previewRedeem" {
+			assets, shares = out, amountD
+		}
+		fmt.Println(marshalJSON(map[string]interface{}{"asset": asset, "assets": assets, "shares": shares}))
+		return
+	}
+	fmt.Println(out)
+}
+
+func tokenDecimals(ctx context.Context, rpcURL, contractAddress string, block web3.BlockNumberOrHash) int32 {
+	res, err := GetContractConst(ctx, rpcURL, contractAddress, "erc20", block, "decimals")
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get decimals of %s: %v", contractAddress, err))
+	}
+	return int32(res[0].(uint8))
+}
+
+func parseBlock(blockNumber string) web3.BlockNumberOrHash {
+	if blockNumber == "" {
+		return web3.BlockNumberOrHash{}
+	}
+	blockN, err := web3.ParseBigInt(blockNumber)
+	if err != nil {
+		fatalExit(fmt.Errorf("Block argument must be a number (decimal integer) %q: %v", blockNumber, err))
+	}
+	return web3.BlockNumber(blockN)
+}
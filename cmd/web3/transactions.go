@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/gochain/gochain/v4/accounts/abi"
 	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
 	"github.com/gochain/gochain/v4/core/types"
 	"github.com/gochain/web3"
 	"github.com/shopspring/decimal"
@@ -33,53 +39,51 @@ func IncreaseGas(ctx context.Context, privateKey string, network web3.Network, t
 		fmt.Printf("failed to parse amount %q: %v", amountGwei, err)
 		return
 	}
-	newPrice := new(big.Int).Add(txOrig.GasPrice, amount)
-	_ = ReplaceTx(ctx, privateKey, network, txOrig.Nonce, *txOrig.To, txOrig.Value, newPrice, txOrig.GasLimit, txOrig.Input)
-	fmt.Printf("Increased gas price to %v\n", newPrice)
+	opts := web3.TxOpts{GasLimit: txOrig.GasLimit}
+	if txOrig.Type == web3.DynamicFeeTxType {
+		opts.MaxFeePerGas = new(big.Int).Add(txOrig.MaxFeePerGas, amount)
+		opts.MaxPriorityFeePerGas = new(big.Int).Add(txOrig.MaxPriorityFeePerGas, amount)
+	} else {
+		opts.GasPrice = new(big.Int).Add(txOrig.GasPrice, amount)
+	}
+	_ = ReplaceTx(ctx, privateKey, network, txOrig.Nonce, *txOrig.To, txOrig.Value, opts, txOrig.Input)
+	if opts.GasPrice != nil {
+		fmt.Printf("Increased gas price to %v\n", opts.GasPrice)
+	} else {
+		fmt.Printf("Increased max fee to %v and priority fee to %v\n", opts.MaxFeePerGas, opts.MaxPriorityFeePerGas)
+	}
+}
+
+// withNonces returns opts with a nonce manager for signer unless the nonce is set, so that a transaction
+// whose nonce was taken, e.g. by one sent just before by another run, is sent again with the next nonce.
+func withNonces(client web3.Client, signer web3.Signer, opts web3.TxOpts) web3.TxOpts {
+	if opts.Nonce == nil && opts.Nonces == nil {
+		opts.Nonces = web3.NewNonceManager(client, signer.Address())
+	}
+	return opts
 }
 
+// ReplaceTx sends a transaction with the given nonce, replacing any pending transaction with the same nonce.
+// The fees must be higher than those of the pending transaction for it to be replaced.
 func ReplaceTx(ctx context.Context, privateKey string, network web3.Network, nonce uint64, to common.Address, amount *big.Int,
-	gasPrice *big.Int, gasLimit uint64, data []byte) *types.Transaction {
+	opts web3.TxOpts, data []byte) *web3.Transaction {
 	client, err := web3.Dial(network.URL)
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
 	}
 	defer client.Close()
-	if gasPrice == nil {
-		gasPrice, err = client.GetGasPrice(ctx)
-		if err != nil {
-			fatalExit(fmt.Errorf("couldn't get suggested gas price: %v", err))
-		}
-		fmt.Printf("Using suggested gas price: %v\n", gasPrice)
-	}
-	chainID := network.ChainID
-	if chainID == nil {
-		chainID, err = client.GetChainID(ctx)
-		if err != nil {
-			fatalExit(fmt.Errorf("couldn't get chain ID: %v", err))
-		}
-	}
-	tx := types.NewTransaction(nonce, to, amount, gasLimit, gasPrice, data)
-	acct, err := web3.ParsePrivateKey(privateKey)
-	if err != nil {
-		fatalExit(err)
-	}
-
-	fmt.Printf("Replacing transaction nonce: %v, gasPrice: %v, gasLimit: %v\n", nonce, gasPrice, gasLimit)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), acct.Key())
-	if err != nil {
-		fatalExit(fmt.Errorf("couldn't sign tx: %v", err))
-	}
-
-	err = web3.SendTransaction(ctx, client, signedTx)
+	client.SetChainID(network.ChainID)
+	opts.Nonce = &nonce
+	tx, err := web3.CallFunctionDataWithSigner(ctx, client, getSigner(privateKey), to.Hex(), amount, opts, data)
 	if err != nil {
 		fatalExit(fmt.Errorf("error sending transaction: %v", err))
 	}
-	fmt.Printf("Replaced transaction. New transaction: %s\n", signedTx.Hash().Hex())
+	fmt.Printf("Transaction nonce: %v, gasPrice: %v, gasLimit: %v\n", nonce, tx.GasPrice, tx.GasLimit)
+	fmt.Printf("Replaced transaction. New transaction: %s\n", tx.Hash.Hex())
 	return tx
 }
 
-func Transfer(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress string, gasPrice *big.Int, gasLimit uint64, wait, toString bool, timeoutInSeconds uint64, tail []string) {
+func Transfer(ctx context.Context, rpcURL string, chainID *big.Int, privateKey, contractAddress string, opts web3.TxOpts, wait, toString bool, timeoutInSeconds uint64, tail []string) {
 	if len(tail) < 3 {
 		fatalExit(errors.New("Invalid arguments. Format is: `transfer X to ADDRESS`"))
 	}
@@ -99,12 +103,13 @@ func Transfer(ctx context.Context, rpcURL string, chainID *big.Int, privateKey,
 	defer client.Close()
 
 	if contractAddress != "" {
-		decimals, err := GetContractConst(ctx, rpcURL, contractAddress, "erc20", "decimals")
+		decimals, err := GetContractConst(ctx, rpcURL, contractAddress, "erc20", web3.BlockNumberOrHash{}, "decimals")
 		if err != nil {
 			fatalExit(err)
 		}
 		amount := web3.DecToInt(amountD, int32(decimals[0].(uint8)))
-		callContract(ctx, client, privateKey, contractAddress, "erc20", "transfer", &big.Int{}, nil, 70000, wait, toString, nil, timeoutInSeconds, toAddress, amount)
+		callContract(ctx, client, privateKey, contractAddress, "erc20", "transfer", &big.Int{}, opts, wait, toString,
+			web3.BlockNumberOrHash{}, nil, nil, timeoutInSeconds, toAddress, amount)
 		return
 	}
 
@@ -116,21 +121,132 @@ func Transfer(ctx context.Context, rpcURL string, chainID *big.Int, privateKey,
 		fatalExit(fmt.Errorf("Invalid to 'address': %s", toAddress))
 	}
 	address := common.HexToAddress(toAddress)
-	tx, err := web3.Send(ctx, client, privateKey, address, amount, gasPrice, gasLimit)
+	signer := getSigner(privateKey)
+	tx, err := web3.SendWithSigner(ctx, client, signer, address, amount, withNonces(client, signer, opts))
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot create transaction: %v", err))
 	}
 	fmt.Println("Transaction address:", tx.Hash.Hex())
 }
 
-func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
-	var logs []web3.Event
-	var err error
-	if myabi != nil && r != nil && r.Logs != nil {
-		logs, err = web3.ParseLogs(*myabi, r.Logs)
-		r.ParsedLogs = logs
+// SignTxOffline builds and signs a transaction without connecting to the network, so the nonce,
+// chain ID, gas limit and fees must all be given. It prints the raw transaction, or with --format json,
+// the raw transaction and its details, or writes them to outFile if set.
+func SignTxOffline(ctx context.Context, privateKey string, chainID *big.Int, to string, amount *big.Int, data []byte,
+	opts web3.TxOpts, outFile string) {
+	var toAddress *common.Address
+	if to != "" {
+		if !common.IsHexAddress(to) {
+			fatalExit(fmt.Errorf("Invalid to 'address': %s", to))
+		}
+		addr := common.HexToAddress(to)
+		toAddress = &addr
+	}
+	tx, err := web3.NewTransaction(chainID, toAddress, amount, data, opts)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot create transaction: %v", err))
+	}
+	raw, err := getSigner(privateKey).SignTx(ctx, tx)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot sign transaction: %v", err))
+	}
+	out := hexutil.Encode(raw)
+	switch format {
+	case "json":
+		out = marshalJSON(map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": tx})
+	}
+	if outFile == "" {
+		fmt.Println(out)
+		return
+	}
+	if err := ioutil.WriteFile(outFile, []byte(out+"\n"), 0600); err != nil {
+		fatalExit(fmt.Errorf("Cannot write signed transaction: %v", err))
+	}
+	fmt.Println("Transaction hash:", tx.Hash.Hex())
+	fmt.Println("Signed transaction:", outFile)
+}
+
+// BroadcastTx sends a signed raw transaction, given in hex, or as a file holding the hex or the
+// JSON output of `web3 tx sign`.
+func BroadcastTx(ctx context.Context, network web3.Network, rawTx string, wait bool, timeoutInSeconds uint64) {
+	if rawTx == "" {
+		fatalExit(errors.New("Missing raw transaction. Format is: `tx broadcast 0xRAW` or `tx broadcast FILE`"))
+	}
+	if !strings.HasPrefix(rawTx, "0x") {
+		b, err := ioutil.ReadFile(rawTx)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read raw transaction: %v", err))
+		}
+		rawTx = strings.TrimSpace(string(b))
+		if strings.HasPrefix(rawTx, "{") {
+			var signed struct {
+				Raw string `json:"raw"`
+			}
+			if err := json.Unmarshal([]byte(rawTx), &signed); err != nil {
+				fatalExit(fmt.Errorf("Invalid signed transaction JSON: %v", err))
+			}
+			rawTx = signed.Raw
+		}
+	}
+	raw, err := hexutil.Decode(rawTx)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid raw transaction: %v", err))
+	}
+	tx, err := web3.DecodeRawTransaction(raw)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid raw transaction: %v", err))
+	}
+	if network.ChainID != nil && tx.ChainID != nil && tx.ChainID.Cmp(network.ChainID) != 0 {
+		fatalExit(fmt.Errorf("Transaction is for chain ID %s, but the %s network is %s", tx.ChainID, network.Name, network.ChainID))
+	}
+	client, err := web3.Dial(network.URL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
+	}
+	defer client.Close()
+	if verbose {
+		log.Printf("Broadcasting transaction from %s with nonce %d", tx.From.Hex(), tx.Nonce)
+	}
+	if err := client.SendRawTransaction(ctx, raw); err != nil {
+		fatalExit(fmt.Errorf("Cannot send transaction: %v", err))
+	}
+	fmt.Println("Transaction hash:", tx.Hash.Hex())
+	if !wait {
+		return
+	}
+	fmt.Println("Waiting for receipt...")
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutInSeconds)*time.Second)
+	defer cancel()
+	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
+	if err != nil {
+		fatalExit(fmt.Errorf("getting receipt: %v", err))
+	}
+	printReceiptDetails(ctx, client, receipt, nil, nil)
+}
+
+// printReceiptDetails prints r, parsing its logs with myabi, if given, and decoding its revert
+// reason with the custom errors abiErrs.
+func printReceiptDetails(ctx context.Context, client web3.Client, r *web3.Receipt, myabi *abi.ABI, abiErrs []web3.ABIError) {
+	// Logs from other contracts, or of events missing from the ABI, are parsed with known signatures.
+	for _, l := range r.Logs {
+		e, err := parseLog(myabi, l)
 		if err != nil {
-			fmt.Printf("ERROR: Cannot parse the receipt logs: %v\ncontinuing...\n", err)
+			fmt.Printf("ERROR: Cannot parse receipt log %d: %v\ncontinuing...\n", l.Index, err)
+		}
+		if e != nil {
+			r.ParsedLogs = append(r.ParsedLogs, *e)
+		}
+	}
+	var revertErr error
+	if r.Status == types.ReceiptStatusFailed {
+		rerr, err := web3.GetRevertReason(ctx, client, r.TxHash, abiErrs...)
+		switch {
+		case err != nil:
+			revertErr = err
+		case rerr.Reason != "":
+			r.RevertReason = rerr.Reason
+		case len(rerr.Data) > 0:
+			r.RevertReason = hexutil.Encode(rerr.Data)
 		}
 	}
 	switch format {
@@ -151,6 +267,9 @@ func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
 		fmt.Println("Contract Address:", r.ContractAddress.String())
 	}
 	fmt.Println("Gas Used:", r.GasUsed)
+	if r.EffectiveGasPrice != nil {
+		fmt.Println("Effective Gas Price:", web3.WeiAsGwei(r.EffectiveGasPrice), "gwei")
+	}
 	fmt.Println("Cumulative Gas Used:", r.CumulativeGasUsed)
 	var status string
 	switch r.Status {
@@ -162,23 +281,29 @@ func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
 		status = fmt.Sprintf("%d (unrecognized status)", r.Status)
 	}
 	fmt.Println("Status:", status)
+	if r.RevertReason != "" {
+		fmt.Println("Revert Reason:", r.RevertReason)
+	} else if revertErr != nil {
+		fmt.Printf("Revert Reason: unknown (%v)\n", revertErr)
+	}
 	fmt.Println("Post State:", "0x"+common.Bytes2Hex(r.PostState))
 	fmt.Println("Bloom:", "0x"+common.Bytes2Hex(r.Bloom.Bytes()))
 	fmt.Println("Logs:", r.Logs)
-	if myabi != nil {
+	if myabi != nil || len(r.ParsedLogs) > 0 {
 		fmt.Println("Parsed Logs:", marshalJSON(r.ParsedLogs))
 	}
 }
 
 func GetTransactionReceipt(ctx context.Context, rpcURL, txhash, contractFile string) {
 	var myabi *abi.ABI
+	var abiErrs []web3.ABIError
 	client, err := web3.Dial(rpcURL)
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to connect to %q: %v", rpcURL, err))
 	}
 	defer client.Close()
 	if contractFile != "" {
-		myabi, err = web3.GetABI(contractFile)
+		myabi, abiErrs, err = web3.GetABIWithErrors(contractFile)
 		if err != nil {
 			fatalExit(err)
 		}
@@ -191,5 +316,5 @@ func GetTransactionReceipt(ctx context.Context, rpcURL, txhash, contractFile str
 		fmt.Println("Transaction Receipt Details:")
 	}
 
-	printReceiptDetails(r, myabi)
+	printReceiptDetails(ctx, client, r, myabi, abiErrs)
 }
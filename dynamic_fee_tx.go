@@ -0,0 +1,134 @@
+package web3
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/gochain/v4/rlp"
+)
+
+// DynamicFeeTxType is the EIP-2718 type of an EIP-1559 dynamic fee transaction.
+const DynamicFeeTxType = 0x02
+
+// DynamicFeeTx is an EIP-1559 dynamic fee transaction.
+type DynamicFeeTx struct {
+	ChainID              *big.Int
+	Nonce                uint64
+	MaxPriorityFeePerGas *big.Int // wei
+	MaxFeePerGas         *big.Int // wei
+	Gas                  uint64
+	To                   *common.Address `rlp:"nil"` // nil for contract creation
+	Value                *big.Int        // wei
+	Data                 []byte
+	AccessList           AccessList
+
+	// Signature values; V is the y-parity (0 or 1).
+	V, R, S *big.Int
+}
+
+// payload returns the transaction fields, without the signature, in RLP order.
+func (tx *DynamicFeeTx) payload() []interface{} {
+	value := tx.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	accessList := tx.AccessList
+	if accessList == nil {
+		accessList = AccessList{}
+	}
+	return []interface{}{
+		tx.ChainID,
+		tx.Nonce,
+		tx.MaxPriorityFeePerGas,
+		tx.MaxFeePerGas,
+		tx.Gas,
+		tx.To,
+		value,
+		tx.Data,
+		accessList,
+	}
+}
+
+// SigningHash returns the hash to be signed by the sender.
+func (tx *DynamicFeeTx) SigningHash() (common.Hash, error) {
+	b, err := rlp.EncodeToBytes(tx.payload())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{DynamicFeeTxType}, b), nil
+}
+
+// Sign signs the transaction with key, setting its signature values.
+func (tx *DynamicFeeTx) Sign(key *ecdsa.PrivateKey) error {
+	h, err := tx.SigningHash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(h[:], key)
+	if err != nil {
+		return err
+	}
+	return tx.SetSignature(sig)
+}
+
+// SetSignature sets the signature values from a 65 byte [R || S || V] signature, where V is 0 or 1.
+func (tx *DynamicFeeTx) SetSignature(sig []byte) error {
+	if len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("wrong size for signature: got %d, want %d", len(sig), crypto.SignatureLength)
+	}
+	if sig[64] > 1 {
+		return fmt.Errorf("invalid signature recovery id: %d", sig[64])
+	}
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = big.NewInt(int64(sig[64]))
+	return nil
+}
+
+// MarshalBinary returns the EIP-2718 encoding of the signed transaction, as sent to the network.
+func (tx *DynamicFeeTx) MarshalBinary() ([]byte, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, errors.New("transaction is not signed")
+	}
+	b, err := rlp.EncodeToBytes(append(tx.payload(), tx.V, tx.R, tx.S))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{DynamicFeeTxType}, b...), nil
+}
+
+// Hash returns the transaction hash of the signed transaction.
+func (tx *DynamicFeeTx) Hash() (common.Hash, error) {
+	b, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(b), nil
+}
+
+// Sender recovers the address which signed the transaction.
+func (tx *DynamicFeeTx) Sender() (common.Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return common.Address{}, errors.New("transaction is not signed")
+	}
+	h, err := tx.SigningHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if tx.V.BitLen() > 1 || !crypto.ValidateSignatureValues(byte(tx.V.Uint64()), tx.R, tx.S, true) {
+		return common.Address{}, errors.New("invalid transaction signature")
+	}
+	sig := make([]byte, crypto.SignatureLength)
+	tx.R.FillBytes(sig[:32])
+	tx.S.FillBytes(sig[32:64])
+	sig[64] = byte(tx.V.Uint64())
+	pub, err := crypto.SigToPub(h[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
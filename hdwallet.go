@@ -0,0 +1,186 @@
+package web3
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/web3/assets"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultHDPath is the BIP-44 path of the first Ethereum account, m/44'/60'/0'/0/0.
+// HDPath returns the path of the others.
+var DefaultHDPath = HDPath(0)
+
+// bip39Words is the BIP-39 English wordlist, and bip39Index maps each word to its index.
+var (
+	bip39Words = strings.Fields(assets.BIP39English)
+	bip39Index = func() map[string]int {
+		m := make(map[string]int, len(bip39Words))
+		for i, w := range bip39Words {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+// HDPath returns the BIP-44 path of the Ethereum account at index, m/44'/60'/0'/0/index.
+func HDPath(index uint32) accounts.DerivationPath {
+	return accounts.DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0, index}
+}
+
+// ParseHDPath parses a derivation path such as "m/44'/60'/0'/0/1".
+// Paths must start with "m/", since relative paths would be appended to GoChain's root path.
+func ParseHDPath(path string) (accounts.DerivationPath, error) {
+	if !strings.HasPrefix(strings.TrimSpace(path), "m/") {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m/", path)
+	}
+	return accounts.ParseDerivationPath(path)
+}
+
+// NewMnemonic returns a new random BIP-39 English mnemonic of 12, 15, 18, 21 or 24 words.
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", fmt.Errorf("invalid mnemonic length %d: must be 12, 15, 18, 21 or 24 words", words)
+	}
+	entropy := make([]byte, words/3*4)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic returns the BIP-39 English mnemonic encoding entropy, which must be 16 to 32 bytes,
+// in multiples of 4.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", fmt.Errorf("invalid entropy length %d: must be 16 to 32 bytes, in multiples of 4", len(entropy))
+	}
+	checksum := sha256.Sum256(entropy)
+	// The entropy is followed by len(entropy)/4 checksum bits, and split into 11 bit word indexes.
+	bits := new(big.Int).SetBytes(append(append([]byte{}, entropy...), checksum[0]))
+	bits.Rsh(bits, uint(8-len(entropy)/4))
+	words := make([]string, (len(entropy)*8+len(entropy)/4)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39Words[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy returns the entropy encoded by a BIP-39 English mnemonic, checking its length,
+// words and checksum. Words are case insensitive.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(normalizeMnemonic(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("invalid mnemonic length %d: must be 12, 15, 18, 21 or 24 words", len(words))
+	}
+	bits := new(big.Int)
+	for _, w := range words {
+		i, ok := bip39Index[w]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word %q", w)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(i)))
+	}
+	checksumBits := len(words) / 3
+	checksum := new(big.Int).And(bits, big.NewInt(1<<uint(checksumBits)-1)).Int64()
+	bits.Rsh(bits, uint(checksumBits))
+	entropy := make([]byte, checksumBits*32/8)
+	bits.FillBytes(entropy)
+	if want := sha256.Sum256(entropy); int64(want[0]>>uint(8-checksumBits)) != checksum {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
+
+// normalizeMnemonic returns mnemonic in NFKD form, and lower case.
+func normalizeMnemonic(mnemonic string) string {
+	return strings.ToLower(norm.NFKD.String(mnemonic))
+}
+
+// ValidateMnemonic returns an error if mnemonic isn't a valid BIP-39 English mnemonic.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed returns the 64 byte BIP-39 seed of mnemonic, which is validated first,
+// and the optional passphrase. The mnemonic is lower cased, as its words are case insensitive,
+// so that it always has the same seed.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	mnemonic = strings.Join(strings.Fields(normalizeMnemonic(mnemonic)), " ")
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(mnemonic), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+// DeriveAccount returns the account at the BIP-32 path of the seed.
+func DeriveAccount(seed []byte, path accounts.DerivationPath) (*Account, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d: must be 16 to 64 bytes", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+	if _, err := crypto.ToECDSA(key); err != nil {
+		return nil, fmt.Errorf("invalid master key: %v", err)
+	}
+	n := crypto.S256().Params().N
+	for _, index := range path {
+		data := make([]byte, 0, 37)
+		if index >= 0x80000000 {
+			data = append(append(data, 0), key...)
+		} else {
+			parent, err := crypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, crypto.CompressPubkey(&parent.PublicKey)...)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at %s, use another index", path)
+		}
+		child := il.Add(il, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if child.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at %s, use another index", path)
+		}
+		key, chainCode = child.FillBytes(make([]byte, 32)), sum[32:]
+	}
+	k, err := crypto.ToECDSA(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{key: k}, nil
+}
+
+// MnemonicAccount returns the account at the BIP-32 path of a BIP-39 mnemonic and optional passphrase,
+// such as HDPath(i) for the i'th account of wallets like MetaMask.
+func MnemonicAccount(mnemonic, passphrase string, path accounts.DerivationPath) (*Account, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveAccount(seed, path)
+}
@@ -0,0 +1,171 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+)
+
+// humanABIPrefixes are the prefixes of human-readable ABI entries.
+var humanABIPrefixes = []string{"function ", "event ", "error ", "constructor(", "constructor ", "fallback(", "receive("}
+
+// isHumanABI reports whether s is a human-readable ABI, rather than a file name or URL.
+func isHumanABI(s string) bool {
+	s = strings.TrimSpace(s)
+	for _, p := range humanABIPrefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHumanABIJSON reports whether b holds a human-readable ABI rather than a JSON ABI.
+func isHumanABIJSON(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	if !bytes.HasPrefix(b, []byte("[")) {
+		return len(b) > 0
+	}
+	var sigs []string
+	return json.Unmarshal(b, &sigs) == nil && len(sigs) > 0
+}
+
+// humanABIEntry is a JSON ABI entry.
+type humanABIEntry struct {
+	Type            string                   `json:"type"`
+	Name            string                   `json:"name,omitempty"`
+	Inputs          []abi.ArgumentMarshaling `json:"inputs"`
+	Outputs         []abi.ArgumentMarshaling `json:"outputs,omitempty"`
+	StateMutability string                   `json:"stateMutability,omitempty"`
+	Anonymous       bool                     `json:"anonymous,omitempty"`
+}
+
+// humanABIToJSON converts a human-readable ABI to JSON. The human-readable ABI is either a JSON array
+// of signature strings, or signatures separated by new lines or semicolons, such as:
+//
+//	function transfer(address to, uint256 amount) returns (bool)
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//
+// Blank lines and lines starting with '#' or '//' are ignored.
+func humanABIToJSON(b []byte) ([]byte, error) {
+	var sigs []string
+	if b = bytes.TrimSpace(b); bytes.HasPrefix(b, []byte("[")) {
+		if err := json.Unmarshal(b, &sigs); err != nil {
+			return nil, err
+		}
+	} else {
+		sigs = strings.FieldsFunc(string(b), func(r rune) bool { return r == '\n' || r == ';' })
+	}
+	entries := []humanABIEntry{}
+	for _, sig := range sigs {
+		sig = strings.TrimSpace(sig)
+		if sig == "" || strings.HasPrefix(sig, "#") || strings.HasPrefix(sig, "//") {
+			continue
+		}
+		e, err := parseHumanABIEntry(sig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABI entry %q: %v", sig, err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no ABI entries")
+	}
+	return json.Marshal(entries)
+}
+
+func parseHumanABIEntry(s string) (humanABIEntry, error) {
+	kind := s
+	if i := strings.IndexAny(s, " ("); i >= 0 {
+		kind = s[:i]
+	}
+	e := humanABIEntry{Type: kind}
+	rest := strings.TrimSpace(s[len(kind):])
+	switch kind {
+	case "function", "event", "error":
+		i := strings.Index(rest, "(")
+		if i <= 0 {
+			return e, errors.New("missing name")
+		}
+		e.Name, rest = strings.TrimSpace(rest[:i]), rest[i:]
+	case "constructor", "fallback", "receive":
+	default:
+		return e, fmt.Errorf("unknown entry type %q", kind)
+	}
+	var err error
+	e.Inputs, rest, err = parseParamList(rest)
+	if err != nil {
+		return e, err
+	}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		if strings.HasPrefix(rest, "returns") && kind == "function" {
+			e.Outputs, rest, err = parseParamList(strings.TrimSpace(strings.TrimPrefix(rest, "returns")))
+			if err != nil {
+				return e, fmt.Errorf("invalid returns: %v", err)
+			}
+			continue
+		}
+		word := strings.Fields(rest)[0]
+		rest = rest[len(word):]
+		switch word {
+		case "view", "pure", "payable", "nonpayable":
+			e.StateMutability = word
+		case "constant":
+			e.StateMutability = "view"
+		case "anonymous":
+			if kind != "event" {
+				return e, fmt.Errorf("unexpected %q", word)
+			}
+			e.Anonymous = true
+		case "external", "public", "virtual", "override":
+		default:
+			return e, fmt.Errorf("unexpected %q", word)
+		}
+	}
+	switch kind {
+	case "function", "constructor", "fallback":
+		if e.StateMutability == "" {
+			e.StateMutability = "nonpayable"
+		}
+	case "receive":
+		e.StateMutability = "payable"
+	}
+	return e, nil
+}
+
+// parseParamList parses the parenthesized parameter list which s starts with, returning the rest of s.
+func parseParamList(s string) ([]abi.ArgumentMarshaling, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", errors.New("missing parameter list")
+	}
+	depth := 0
+	end := strings.IndexFunc(s, func(r rune) bool {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		return depth == 0
+	})
+	if end < 0 {
+		return nil, "", errors.New("unbalanced parentheses")
+	}
+	params, err := splitParams(s[1:end])
+	if err != nil {
+		return nil, "", err
+	}
+	args := []abi.ArgumentMarshaling{}
+	for _, p := range params {
+		m, err := paramMarshaling(p)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, m)
+	}
+	return args, s[end+1:], nil
+}
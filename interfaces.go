@@ -0,0 +1,252 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/crypto"
+)
+
+// Interface is a standard contract interface, identified by its ERC165 interface ID.
+type Interface struct {
+	Name string
+	ID   [4]byte
+}
+
+// KnownInterfaces are the ERC165 interfaces probed by DetectStandards.
+var KnownInterfaces = []Interface{
+	{"ERC165", [4]byte{0x01, 0xff, 0xc9, 0xa7}},
+	{"ERC721", [4]byte{0x80, 0xac, 0x58, 0xcd}},
+	{"ERC721Metadata", [4]byte{0x5b, 0x5e, 0x13, 0x9f}},
+	{"ERC721Enumerable", [4]byte{0x78, 0x0e, 0x9d, 0x63}},
+	{"ERC1155", [4]byte{0xd9, 0xb6, 0x7a, 0x26}},
+	{"ERC1155MetadataURI", [4]byte{0x0e, 0x89, 0x34, 0x1c}},
+	{"ERC2981", [4]byte{0x2a, 0x55, 0x20, 0x5a}},
+}
+
+// selectorStandards are standards without ERC165 support, detected by their functions' selectors in
+// the contract code.
+var selectorStandards = []struct {
+	name string
+	sigs []string
+}{
+	{"ERC20", []string{"totalSupply()", "balanceOf(address)", "transfer(address,uint256)",
+		"transferFrom(address,address,uint256)", "approve(address,uint256)", "allowance(address,address)"}},
+	{"ERC2612", []string{"permit(address,address,uint256,uint256,uint8,bytes32,bytes32)", "nonces(address)",
+		"DOMAIN_SEPARATOR()"}},
+	{"ERC4626", []string{"asset()", "totalAssets()", "convertToShares(uint256)", "convertToAssets(uint256)",
+		"previewDeposit(uint256)", "deposit(uint256,address)", "previewRedeem(uint256)", "redeem(uint256,address,address)"}},
+}
+
+// SupportsInterface reports whether a contract supports an interface, according to its ERC165
+// supportsInterface function. Contracts which don't implement ERC165 don't support any interface.
+func SupportsInterface(ctx context.Context, client Client, address common.Address, id [4]byte) (bool, error) {
+	// As specified by ERC165, check that the contract implements it first.
+	if ok, err := supportsInterface(ctx, client, address, KnownInterfaces[0].ID); err != nil || !ok {
+		return false, err
+	}
+	if ok, err := supportsInterface(ctx, client, address, [4]byte{0xff, 0xff, 0xff, 0xff}); err != nil || ok {
+		return false, err
+	}
+	if id == KnownInterfaces[0].ID {
+		return true, nil
+	}
+	return supportsInterface(ctx, client, address, id)
+}
+
+func supportsInterface(ctx context.Context, client Client, address common.Address, id [4]byte) (bool, error) {
+	data := append(crypto.Keccak256([]byte("supportsInterface(bytes4)"))[:4], common.RightPadBytes(id[:], 32)...)
+	res, err := client.Call(ctx, CallMsg{To: &address, Data: data})
+	if err != nil {
+		if _, ok := err.(*RevertError); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(res) == 32 && new(big.Int).SetBytes(res).Cmp(big.NewInt(1)) == 0, nil
+}
+
+// DetectStandards returns the names of the standards implemented by a contract. Interfaces declared
+// through ERC165 are probed, while ERC20, ERC2612 and ERC4626, which don't use it, are detected by the
+// presence of their function selectors in the contract's code, or its implementation's if it is a proxy.
+func DetectStandards(ctx context.Context, client Client, address common.Address) ([]string, error) {
+	code, err := client.GetCode(ctx, address.Hex(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code: %v", err)
+	}
+	if len(code) == 0 {
+		return nil, nil
+	}
+	// Proxies dispatch calls in their implementation's code.
+	proxy, err := ResolveProxy(ctx, client, address)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		code, err = client.GetCode(ctx, proxy.Implementation.Hex(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get implementation code: %v", err)
+		}
+	}
+	var standards []string
+	for _, s := range selectorStandards {
+		if HasSelectors(code, s.sigs...) {
+			standards = append(standards, s.name)
+		}
+	}
+	if ok, err := SupportsInterface(ctx, client, address, KnownInterfaces[0].ID); err != nil {
+		return nil, err
+	} else if !ok {
+		return standards, nil
+	}
+	standards = append(standards, KnownInterfaces[0].Name)
+	for _, i := range KnownInterfaces[1:] {
+		ok, err := supportsInterface(ctx, client, address, i.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			standards = append(standards, i.Name)
+		}
+	}
+	return standards, nil
+}
+
+// HasSelectors reports whether code pushes the selectors of all of the function signatures, as
+// compilers do to dispatch calls. This is a heuristic, since code may dispatch calls in other ways.
+func HasSelectors(code []byte, sigs ...string) bool {
+	for _, sig := range sigs {
+		selector := bytes.TrimLeft(crypto.Keccak256([]byte(sig))[:4], "\x00")
+		// PUSH1 to PUSH4, since leading zeros are dropped.
+		push := append([]byte{byte(0x5f + len(selector))}, selector...)
+		if !bytes.Contains(code, push) {
+			return false
+		}
+	}
+	return true
+}
+
+// CodeMetadata is the compiler metadata appended to contract code by solc and vyper.
+type CodeMetadata struct {
+	Compiler     string `json:"compiler"` // solc or vyper
+	Version      string `json:"version,omitempty"`
+	IPFS         string `json:"ipfs,omitempty"`  // hex hash of the metadata file
+	Swarm        string `json:"swarm,omitempty"` // hex hash of the metadata file
+	Experimental bool   `json:"experimental,omitempty"`
+}
+
+// ParseCodeMetadata parses the CBOR encoded compiler metadata from the end of contract code, whose
+// length is given by the last two bytes.
+func ParseCodeMetadata(code []byte) (*CodeMetadata, bool) {
+	if len(code) < 2 {
+		return nil, false
+	}
+	n := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	if n == 0 || n > len(code)-2 {
+		return nil, false
+	}
+	fields, ok := parseCBORMap(code[len(code)-2-n : len(code)-2])
+	if !ok {
+		return nil, false
+	}
+	var md CodeMetadata
+	for k, v := range fields {
+		switch k {
+		case "solc", "vyper":
+			md.Compiler = k
+			switch v := v.(type) {
+			case string:
+				md.Version = v
+			case []byte:
+				parts := make([]string, len(v))
+				for i, b := range v {
+					parts[i] = fmt.Sprint(b)
+				}
+				md.Version = strings.Join(parts, ".")
+			}
+		case "ipfs":
+			if b, ok := v.([]byte); ok {
+				md.IPFS = common.Bytes2Hex(b)
+			}
+		case "bzzr0", "bzzr1":
+			if b, ok := v.([]byte); ok {
+				md.Swarm = common.Bytes2Hex(b)
+			}
+		case "experimental":
+			md.Experimental, _ = v.(bool)
+		}
+	}
+	if md.Compiler == "" && md.IPFS == "" && md.Swarm == "" {
+		return nil, false
+	}
+	if md.Compiler == "" {
+		// solc before 0.5.9 only included the hash.
+		md.Compiler = "solc"
+	}
+	return &md, true
+}
+
+// parseCBORMap parses a CBOR map with string keys and byte string, text string or boolean values,
+// which is all that compilers use for metadata.
+func parseCBORMap(b []byte) (map[string]interface{}, bool) {
+	if len(b) == 0 || b[0]>>5 != 5 {
+		return nil, false
+	}
+	n, b, ok := cborLength(b)
+	if !ok {
+		return nil, false
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		if len(b) == 0 || b[0]>>5 != 3 {
+			return nil, false
+		}
+		var l int
+		l, b, ok = cborLength(b)
+		if !ok || l > len(b) {
+			return nil, false
+		}
+		key := string(b[:l])
+		b = b[l:]
+		if len(b) == 0 {
+			return nil, false
+		}
+		switch major := b[0] >> 5; {
+		case b[0] == 0xf4 || b[0] == 0xf5:
+			m[key] = b[0] == 0xf5
+			b = b[1:]
+		case major == 2 || major == 3:
+			l, b, ok = cborLength(b)
+			if !ok || l > len(b) {
+				return nil, false
+			}
+			if major == 2 {
+				m[key] = append([]byte(nil), b[:l]...)
+			} else {
+				m[key] = string(b[:l])
+			}
+			b = b[l:]
+		default:
+			return nil, false
+		}
+	}
+	return m, len(b) == 0
+}
+
+// cborLength decodes the length from a CBOR item's header, returning the rest of b.
+func cborLength(b []byte) (int, []byte, bool) {
+	switch info := b[0] & 0x1f; {
+	case info < 24:
+		return int(info), b[1:], true
+	case info == 24 && len(b) >= 2:
+		return int(b[1]), b[2:], true
+	case info == 25 && len(b) >= 3:
+		return int(binary.BigEndian.Uint16(b[1:])), b[3:], true
+	}
+	return 0, nil, false
+}
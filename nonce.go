@@ -0,0 +1,144 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gochain/gochain/v4/common"
+)
+
+// maxNonceRetries is the number of times a transaction is re-signed with a fresh nonce after a nonce conflict.
+const maxNonceRetries = 3
+
+// NonceManager hands out sequential nonces for an account, so that transactions can be sent
+// concurrently or in quick succession without waiting for the node's pending nonce to catch up.
+// Set TxOpts.Nonces to use it, which also re-signs transactions with a fresh nonce when another
+// transaction took theirs. It is safe for concurrent use.
+//
+// The first nonce is the account's pending nonce. Nonces of transactions which fail to send are
+// released and handed out again, so they don't leave gaps which would block later transactions.
+type NonceManager struct {
+	client  Client
+	address common.Address
+
+	mu       sync.Mutex
+	synced   bool
+	next     uint64
+	released []uint64 // sorted
+}
+
+// NewNonceManager returns a nonce manager for address.
+func NewNonceManager(client Client, address common.Address) *NonceManager {
+	return &NonceManager{client: client, address: address}
+}
+
+// Address returns the account address.
+func (m *NonceManager) Address() common.Address {
+	return m.address
+}
+
+// Next returns the next nonce to use: the lowest released nonce if any, or else one more than
+// the last nonce handed out. The first call fetches the pending nonce from the network.
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.synced {
+		if err := m.sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+	if len(m.released) > 0 {
+		nonce := m.released[0]
+		m.released = m.released[1:]
+		return nonce, nil
+	}
+	nonce := m.next
+	m.next++
+	return nonce, nil
+}
+
+// Release returns a nonce from Next which wasn't used, because its transaction couldn't be sent,
+// so that it is handed out again.
+func (m *NonceManager) Release(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.synced || nonce >= m.next {
+		return
+	}
+	if nonce == m.next-1 {
+		m.next--
+		// Trailing released nonces are no longer gaps.
+		for n := len(m.released); n > 0 && m.released[n-1] == m.next-1; n-- {
+			m.released = m.released[:n-1]
+			m.next--
+		}
+		return
+	}
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= nonce })
+	if i < len(m.released) && m.released[i] == nonce {
+		return
+	}
+	m.released = append(m.released, 0)
+	copy(m.released[i+1:], m.released[i:])
+	m.released[i] = nonce
+}
+
+// Sync fetches the pending nonce from the network, skipping any nonces below it. Nonces already
+// handed out above it are kept, so they aren't handed out twice.
+func (m *NonceManager) Sync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sync(ctx)
+}
+
+func (m *NonceManager) sync(ctx context.Context) error {
+	pending, err := m.client.GetPendingTransactionCount(ctx, m.address)
+	if err != nil {
+		return fmt.Errorf("cannot get nonce: %v", err)
+	}
+	if !m.synced || pending > m.next {
+		m.next = pending
+	}
+	m.synced = true
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= pending })
+	m.released = m.released[i:]
+	return nil
+}
+
+// Reset forgets all nonces, so the next call to Next starts again from the pending nonce.
+// Use it after transactions were dropped by the network.
+func (m *NonceManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+	m.released = nil
+}
+
+// IsNonceError reports whether err is a node's rejection of a transaction because another
+// transaction already used its nonce: "nonce too low" or "replacement transaction underpriced".
+func IsNonceError(err error) bool {
+	return errorContains(err, "nonce too low", "replacement transaction underpriced")
+}
+
+// IsKnownTxError reports whether err is a node's rejection of a transaction because it already
+// has the same transaction: "already known" or "known transaction".
+func IsKnownTxError(err error) bool {
+	return errorContains(err, "already known", "known transaction")
+}
+
+// errorContains reports whether the lower case message of err contains any of msgs.
+func errorContains(err error, msgs ...string) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range msgs {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
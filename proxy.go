@@ -0,0 +1,182 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/crypto"
+)
+
+// Proxy kinds recognized by ResolveProxy.
+const (
+	ProxyEIP1967       = "EIP-1967"
+	ProxyEIP1967Beacon = "EIP-1967 beacon"
+	ProxyEIP1822       = "EIP-1822"
+	ProxyEIP1167       = "EIP-1167"
+	ProxyOwner         = "OwnerUpgradeableProxy"
+	ProxyTarget        = "target()"
+)
+
+// Storage slots holding the addresses of proxies' implementation, admin and beacon.
+var (
+	// bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+	EIP1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// bytes32(uint256(keccak256("eip1967.proxy.admin")) - 1)
+	EIP1967AdminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	// bytes32(uint256(keccak256("eip1967.proxy.beacon")) - 1)
+	EIP1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// keccak256("PROXIABLE")
+	EIP1822ProxiableSlot = crypto.Keccak256Hash([]byte("PROXIABLE"))
+	// keccak256("gochain.proxy.target"), used by the bundled OwnerUpgradeableProxy.
+	OwnerProxyTargetSlot = crypto.Keccak256Hash([]byte("gochain.proxy.target"))
+)
+
+// Proxy is a proxy contract, which delegates calls to an implementation contract.
+type Proxy struct {
+	Kind           string          `json:"kind"`
+	Implementation common.Address  `json:"implementation"`
+	Admin          *common.Address `json:"admin,omitempty"`
+	Beacon         *common.Address `json:"beacon,omitempty"`
+}
+
+// ResolveProxy returns the proxy at an address, or nil if it isn't a recognized proxy. EIP-1167 minimal
+// proxies are recognized by their code, EIP-1967, EIP-1822 and OwnerUpgradeableProxy proxies by their
+// storage slots, and other proxies by a target() function.
+func ResolveProxy(ctx context.Context, client Client, address common.Address) (*Proxy, error) {
+	code, err := client.GetCode(ctx, address.Hex(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code: %v", err)
+	}
+	if len(code) == 0 {
+		return nil, nil
+	}
+	if impl, ok := MinimalProxyTarget(code); ok {
+		return &Proxy{Kind: ProxyEIP1167, Implementation: impl}, nil
+	}
+
+	impl, err := storageAddress(ctx, client, address, EIP1967ImplementationSlot)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		p := &Proxy{Kind: ProxyEIP1967, Implementation: *impl}
+		if p.Admin, err = storageAddress(ctx, client, address, EIP1967AdminSlot); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	beacon, err := storageAddress(ctx, client, address, EIP1967BeaconSlot)
+	if err != nil {
+		return nil, err
+	}
+	if beacon != nil {
+		impl, err := callAddress(ctx, client, *beacon, "implementation()")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get implementation from beacon %s: %v", beacon.Hex(), err)
+		}
+		if impl == nil {
+			return nil, fmt.Errorf("beacon %s has no implementation", beacon.Hex())
+		}
+		return &Proxy{Kind: ProxyEIP1967Beacon, Implementation: *impl, Beacon: beacon}, nil
+	}
+
+	for _, s := range []struct {
+		kind string
+		slot common.Hash
+	}{
+		{ProxyEIP1822, EIP1822ProxiableSlot},
+		{ProxyOwner, OwnerProxyTargetSlot},
+	} {
+		impl, err := storageAddress(ctx, client, address, s.slot)
+		if err != nil {
+			return nil, err
+		}
+		if impl != nil {
+			return &Proxy{Kind: s.kind, Implementation: *impl}, nil
+		}
+	}
+
+	if !HasSelectors(code, "target()") {
+		return nil, nil
+	}
+	impl, err = callAddress(ctx, client, address, "target()")
+	if err != nil {
+		if _, ok := err.(*RevertError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if impl == nil {
+		return nil, nil
+	}
+	return &Proxy{Kind: ProxyTarget, Implementation: *impl}, nil
+}
+
+// ProxyAdminOwner returns the owner of an EIP-1967 proxy's admin contract, such as OpenZeppelin's
+// ProxyAdmin, or nil if admin isn't a contract with an owner() function.
+func ProxyAdminOwner(ctx context.Context, client Client, admin common.Address) (*common.Address, error) {
+	code, err := client.GetCode(ctx, admin.Hex(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code: %v", err)
+	}
+	if !HasSelectors(code, "owner()") {
+		return nil, nil
+	}
+	owner, err := callAddress(ctx, client, admin, "owner()")
+	if err != nil {
+		if _, ok := err.(*RevertError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return owner, nil
+}
+
+// storageAddress returns the address in a storage slot, or nil if it is empty or isn't an address.
+func storageAddress(ctx context.Context, client Client, address common.Address, slot common.Hash) (*common.Address, error) {
+	val, err := client.GetStorageAt(ctx, address, slot, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage slot %s: %v", slot.Hex(), err)
+	}
+	return wordAddress(val[:]), nil
+}
+
+// callAddress calls a function without arguments which returns an address, returning nil for the zero address.
+func callAddress(ctx context.Context, client Client, address common.Address, sig string) (*common.Address, error) {
+	res, err := client.Call(ctx, CallMsg{To: &address, Data: crypto.Keccak256([]byte(sig))[:4]})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 32 {
+		return nil, nil
+	}
+	return wordAddress(res), nil
+}
+
+// wordAddress returns the address held by a 32 byte word, or nil if it is zero or has other high bytes set.
+func wordAddress(word []byte) *common.Address {
+	if new(big.Int).SetBytes(word).Sign() == 0 || !bytes.Equal(word[:12], make([]byte, 12)) {
+		return nil
+	}
+	addr := common.BytesToAddress(word[12:])
+	return &addr
+}
+
+// minimalProxyPrefix and minimalProxySuffix surround the implementation address in EIP-1167 minimal
+// proxy code.
+var (
+	minimalProxyPrefix = common.FromHex("0x363d3d373d3d3d363d73")
+	minimalProxySuffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// MinimalProxyTarget returns the implementation address of EIP-1167 minimal proxy code.
+func MinimalProxyTarget(code []byte) (common.Address, bool) {
+	if len(code) != len(minimalProxyPrefix)+common.AddressLength+len(minimalProxySuffix) ||
+		!bytes.HasPrefix(code, minimalProxyPrefix) || !bytes.HasSuffix(code, minimalProxySuffix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(code[len(minimalProxyPrefix) : len(minimalProxyPrefix)+common.AddressLength]), true
+}
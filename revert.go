@@ -0,0 +1,248 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/gochain/v4/rpc"
+)
+
+var (
+	// errorSelector is the selector of Error(string), used by revert and require.
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	// panicSelector is the selector of Panic(uint256), used by assert and checked arithmetic.
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// panicCodes describes the codes of Panic(uint256) errors raised by the Solidity compiler.
+var panicCodes = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to zero-initialized internal function",
+}
+
+// RevertError is returned when a call reverts.
+type RevertError struct {
+	Data   []byte // raw revert data, if returned by the node
+	Reason string // decoded from Data (see DecodeRevertReason), empty if unknown
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case len(e.Data) > 0:
+		return "execution reverted: " + hexutil.Encode(e.Data)
+	}
+	return "execution reverted"
+}
+
+// toRevertError converts an RPC error from a reverted call into a *RevertError, decoding its
+// reason with DecodeRevertReason and the custom errors errs. Other errors are returned unchanged.
+func toRevertError(err error, errs ...ABIError) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if de, ok := err.(rpc.DataError); ok {
+		if s, ok := de.ErrorData().(string); ok {
+			if data, err := hexutil.Decode(s); err == nil {
+				reason, _ := DecodeRevertReason(data, errs...)
+				return &RevertError{Data: data, Reason: reason}
+			}
+		}
+	}
+	// Some nodes only include the reason in the message.
+	if strings.HasPrefix(msg, "execution reverted") {
+		return &RevertError{Reason: strings.TrimPrefix(strings.TrimPrefix(msg, "execution reverted"), ": ")}
+	}
+	return err
+}
+
+// DecodeRevertReason decodes revert data as an Error(string) reason, a Panic(uint256) code,
+// or one of the custom errors errs, such as those of an ABI read by GetABIWithErrors.
+func DecodeRevertReason(data []byte, errs ...ABIError) (string, error) {
+	if len(data) < 4 {
+		return "", errors.New("no revert data")
+	}
+	selector, args := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		vals, err := abi.Arguments{{Type: mustNewType("string")}}.UnpackValues(args)
+		if err != nil {
+			return "", fmt.Errorf("invalid Error(string) data: %v", err)
+		}
+		return vals[0].(string), nil
+	case bytes.Equal(selector, panicSelector):
+		vals, err := abi.Arguments{{Type: mustNewType("uint256")}}.UnpackValues(args)
+		if err != nil {
+			return "", fmt.Errorf("invalid Panic(uint256) data: %v", err)
+		}
+		code := vals[0].(*big.Int)
+		desc := "unknown panic code"
+		if d, ok := panicCodes[code.Uint64()]; ok && code.IsUint64() {
+			desc = d
+		}
+		return fmt.Sprintf("panic: %s (0x%x)", desc, code), nil
+	}
+	var id [4]byte
+	copy(id[:], selector)
+	e, ok := findError(errs, id)
+	if !ok {
+		return "", fmt.Errorf("unknown error selector %s", hexutil.Encode(selector))
+	}
+	vals, err := e.Inputs.UnpackValues(args)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s data: %v", e.Sig(), err)
+	}
+	fields := make([]string, len(vals))
+	for i, v := range vals {
+		if name := e.Inputs[i].Name; name != "" {
+			fields[i] = fmt.Sprintf("%s: %v", name, v)
+		} else {
+			fields[i] = fmt.Sprint(v)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(fields, ", ")), nil
+}
+
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
+
+// ABIError is a custom error defined in a contract ABI.
+type ABIError struct {
+	Name   string
+	Inputs abi.Arguments
+}
+
+// Sig returns the error signature, e.g. "InsufficientBalance(uint256,uint256)".
+func (e ABIError) Sig() string {
+	types := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(types, ","))
+}
+
+// ID returns the selector which prefixes the error's revert data.
+func (e ABIError) ID() [4]byte {
+	var id [4]byte
+	copy(id[:], crypto.Keccak256([]byte(e.Sig())))
+	return id
+}
+
+// findError returns the error in errs with the selector id.
+func findError(errs []ABIError, id [4]byte) (ABIError, bool) {
+	for _, e := range errs {
+		if e.ID() == id {
+			return e, true
+		}
+	}
+	return ABIError{}, false
+}
+
+// DecodeRevertError returns err with its reason decoded from the custom errors errs, if it is a
+// *RevertError with data for one of them, or else err unchanged. Reverted calls are only decoded
+// as Error(string) and Panic(uint256), so use it with the errors of the called contract's ABI.
+func DecodeRevertError(err error, errs []ABIError) error {
+	rerr, ok := err.(*RevertError)
+	if !ok || len(rerr.Data) == 0 || len(errs) == 0 {
+		return err
+	}
+	reason, derr := DecodeRevertReason(rerr.Data, errs...)
+	if derr != nil {
+		return err
+	}
+	return &RevertError{Data: rerr.Data, Reason: reason}
+}
+
+// splitABIErrors separates the custom error entries, which abi.JSON does not support,
+// from the rest of a JSON ABI.
+func splitABIErrors(abiJSON []byte) ([]byte, []ABIError, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(abiJSON, &entries); err != nil {
+		return nil, nil, err
+	}
+	var rest []json.RawMessage
+	var errs []ABIError
+	for _, entry := range entries {
+		var typ struct{ Type string }
+		if err := json.Unmarshal(entry, &typ); err != nil {
+			return nil, nil, err
+		}
+		if typ.Type != "error" {
+			rest = append(rest, entry)
+			continue
+		}
+		var e struct {
+			Name   string
+			Inputs abi.Arguments
+		}
+		if err := json.Unmarshal(entry, &e); err != nil {
+			return nil, nil, err
+		}
+		errs = append(errs, ABIError{Name: e.Name, Inputs: e.Inputs})
+	}
+	if len(errs) == 0 {
+		return abiJSON, nil, nil
+	}
+	b, err := json.Marshal(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, errs, nil
+}
+
+// GetRevertReason recovers the reason a failed transaction reverted, by replaying it as a
+// call on the state before its block, and decoding the revert data with the custom errors errs.
+// Transactions earlier in the same block aren't replayed, so the call may succeed, or revert
+// differently, if the transaction depended on them.
+func GetRevertReason(ctx context.Context, client Client, hash common.Hash, errs ...ABIError) (*RevertError, error) {
+	tx, err := client.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get transaction: %v", err)
+	}
+	if tx.BlockNumber == nil {
+		return nil, errors.New("transaction is pending")
+	}
+	msg := CallMsg{
+		From:  &tx.From,
+		To:    tx.To,
+		Gas:   tx.GasLimit,
+		Value: tx.Value,
+		Data:  tx.Input,
+	}
+	block := new(big.Int).Sub(tx.BlockNumber, big.NewInt(1))
+	if block.Sign() < 0 {
+		block.SetInt64(0)
+	}
+	_, err = client.CallAt(ctx, msg, BlockNumber(block), nil)
+	if err == nil {
+		return nil, errors.New("transaction did not revert when replayed")
+	}
+	if rerr, ok := DecodeRevertError(err, errs).(*RevertError); ok {
+		return rerr, nil
+	}
+	return nil, fmt.Errorf("cannot replay transaction: %v", err)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/gochain/gochain/v4/common"
@@ -32,8 +33,10 @@ type rpcBlock struct {
 	MixHash         *common.Hash      `json:"mixHash"`
 	Nonce           *types.BlockNonce `json:"nonce"`
 	Hash            *common.Hash      `json:"hash"`
+	BaseFeePerGas   *hexutil.Big      `json:"baseFeePerGas,omitempty"`
 	Txs             json.RawMessage   `json:"transactions,omitempty"`
 	Uncles          []common.Hash     `json:"uncles"`
+	Withdrawals     []Withdrawal      `json:"withdrawals,omitempty"`
 }
 
 // copyTo copies the fields from r to b.
@@ -98,33 +101,39 @@ func (r *rpcBlock) copyTo(b *Block) error {
 		return errors.New("missing 'extraData")
 	}
 	b.ExtraData = *r.ExtraData
-	if r.MixHash == nil {
-		return errors.New("missing 'mixHash'")
+	// Not all chains have a mix hash and nonce.
+	if r.MixHash != nil {
+		b.MixHash = *r.MixHash
 	}
-	b.MixHash = *r.MixHash
-	if r.Nonce == nil {
-		return errors.New("missing 'nonce'")
+	if r.Nonce != nil {
+		b.Nonce = *r.Nonce
 	}
-	b.Nonce = *r.Nonce
 	if r.Hash == nil {
 		return errors.New("missing 'hash'")
 	}
 	b.Hash = *r.Hash
+	if r.BaseFeePerGas != nil {
+		b.BaseFeePerGas = r.BaseFeePerGas.ToInt()
+	}
 
-	// Try tx hashes first.
-	var hashes []common.Hash
-	if err := json.Unmarshal(r.Txs, &hashes); err == nil {
-		b.TxHashes = hashes
-	} else {
-		// Try full transactions.
-		var details []*Transaction
-		if err := json.Unmarshal(r.Txs, &details); err != nil {
-			return fmt.Errorf("failed to unmarshal transactions as either hahes or details %q: %s", err, string(r.Txs))
+	// Headers, like those from newHeads subscriptions, omit transactions.
+	if len(r.Txs) > 0 {
+		// Try tx hashes first.
+		var hashes []common.Hash
+		if err := json.Unmarshal(r.Txs, &hashes); err == nil {
+			b.TxHashes = hashes
+		} else {
+			// Try full transactions.
+			var details []*Transaction
+			if err := json.Unmarshal(r.Txs, &details); err != nil {
+				return fmt.Errorf("failed to unmarshal transactions as either hahes or details %q: %s", err, string(r.Txs))
+			}
+			b.TxDetails = details
 		}
-		b.TxDetails = details
 	}
 
 	b.Uncles = r.Uncles
+	b.Withdrawals = r.Withdrawals
 	return nil
 }
 
@@ -151,6 +160,7 @@ func (r *rpcBlock) copyFrom(b *Block) error {
 	r.MixHash = &b.MixHash
 	r.Nonce = &b.Nonce
 	r.Hash = &b.Hash
+	r.BaseFeePerGas = (*hexutil.Big)(b.BaseFeePerGas)
 	if b.TxHashes != nil {
 		data, err := json.Marshal(b.TxHashes)
 		if err != nil {
@@ -165,10 +175,12 @@ func (r *rpcBlock) copyFrom(b *Block) error {
 		r.Txs = data
 	}
 	r.Uncles = b.Uncles
+	r.Withdrawals = b.Withdrawals
 	return nil
 }
 
 type rpcTransaction struct {
+	Type     *hexutil.Uint64 `json:"type,omitempty"`
 	Nonce    *hexutil.Uint64 `json:"nonce"`
 	GasPrice *hexutil.Big    `json:"gasPrice"`
 	GasLimit *hexutil.Uint64 `json:"gas"`
@@ -179,23 +191,42 @@ type rpcTransaction struct {
 	V        *hexutil.Big    `json:"v"`
 	R        *hexutil.Big    `json:"r"`
 	S        *hexutil.Big    `json:"s"`
+	YParity  *hexutil.Uint64 `json:"yParity,omitempty"`
 	Hash     *common.Hash    `json:"hash"`
 
+	ChainID              *hexutil.Big `json:"chainId,omitempty"`
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
+	AccessList           *AccessList  `json:"accessList,omitempty"`
+
 	BlockNumber      *hexutil.Big    `json:"blockNumber,omitempty"`
 	BlockHash        *common.Hash    `json:"blockHash,omitempty"`
 	TransactionIndex *hexutil.Uint64 `json:"transactionIndex,omitempty"`
+
+	DecodedInput *CallData `json:"decodedInput,omitempty"`
 }
 
 // copyTo copies the fields from r to t.
 func (r *rpcTransaction) copyTo(t *Transaction) error {
+	if r.Type != nil {
+		t.Type = uint64(*r.Type)
+	}
 	if r.Nonce == nil {
 		return errors.New("missing 'nonce'")
 	}
 	t.Nonce = uint64(*r.Nonce)
-	if r.GasPrice == nil {
+	if r.MaxFeePerGas != nil {
+		t.MaxFeePerGas = r.MaxFeePerGas.ToInt()
+	}
+	if r.MaxPriorityFeePerGas != nil {
+		t.MaxPriorityFeePerGas = r.MaxPriorityFeePerGas.ToInt()
+	}
+	// Some nodes omit the gas price of pending dynamic fee transactions.
+	if r.GasPrice != nil {
+		t.GasPrice = r.GasPrice.ToInt()
+	} else if t.MaxFeePerGas == nil {
 		return errors.New("missing 'gasPrice'")
 	}
-	t.GasPrice = r.GasPrice.ToInt()
 	if r.GasLimit == nil {
 		return errors.New("missing 'gas'")
 	}
@@ -210,10 +241,17 @@ func (r *rpcTransaction) copyTo(t *Transaction) error {
 	if r.Input != nil {
 		t.Input = *r.Input
 	}
-	if r.V == nil {
+	if r.YParity != nil {
+		yParity := uint64(*r.YParity)
+		t.YParity = &yParity
+	}
+	if r.V != nil {
+		t.V = r.V.ToInt()
+	} else if t.YParity != nil {
+		t.V = new(big.Int).SetUint64(*t.YParity)
+	} else {
 		return errors.New("missing 'v'")
 	}
-	t.V = r.V.ToInt()
 	if r.R == nil {
 		return errors.New("missing 'r'")
 	}
@@ -238,11 +276,19 @@ func (r *rpcTransaction) copyTo(t *Transaction) error {
 	if r.TransactionIndex != nil {
 		t.TransactionIndex = uint64(*r.TransactionIndex)
 	}
+	if r.ChainID != nil {
+		t.ChainID = r.ChainID.ToInt()
+	}
+	if r.AccessList != nil {
+		t.AccessList = *r.AccessList
+	}
+	t.DecodedInput = r.DecodedInput
 	return nil
 }
 
 // copyFrom copies the fields from t to r.
 func (r *rpcTransaction) copyFrom(t *Transaction) {
+	r.Type = (*hexutil.Uint64)(&t.Type)
 	r.Nonce = (*hexutil.Uint64)(&t.Nonce)
 	r.GasPrice = (*hexutil.Big)(t.GasPrice)
 	r.GasLimit = (*hexutil.Uint64)(&t.GasLimit)
@@ -257,6 +303,14 @@ func (r *rpcTransaction) copyFrom(t *Transaction) {
 	r.V = (*hexutil.Big)(t.V)
 	r.R = (*hexutil.Big)(t.R)
 	r.S = (*hexutil.Big)(t.S)
+	r.YParity = (*hexutil.Uint64)(t.YParity)
+	r.ChainID = (*hexutil.Big)(t.ChainID)
+	r.MaxFeePerGas = (*hexutil.Big)(t.MaxFeePerGas)
+	r.MaxPriorityFeePerGas = (*hexutil.Big)(t.MaxPriorityFeePerGas)
+	if t.AccessList != nil {
+		r.AccessList = &t.AccessList
+	}
+	r.DecodedInput = t.DecodedInput
 }
 
 type rpcReceipt struct {
@@ -270,10 +324,13 @@ type rpcReceipt struct {
 	ContractAddress   *common.Address `json:"contractAddress"`
 	GasUsed           *hexutil.Uint64 `json:"gasUsed"`
 	ParsedLogs        *[]Event        `json:"parsedLogs"`
+	RevertReason      string          `json:"revertReason,omitempty"`
 	BlockHash         *common.Hash    `json:"blockHash"`
 	BlockNumber       *hexutil.Uint64 `json:"blockNumber"`
 	From              *common.Address `json:"from"`
 	To                *common.Address `json:"to"`
+	Type              *hexutil.Uint64 `json:"type,omitempty"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice,omitempty"`
 }
 
 func (rr *rpcReceipt) copyTo(r *Receipt) error {
@@ -287,7 +344,9 @@ func (rr *rpcReceipt) copyTo(r *Receipt) error {
 		return errors.New("missing 'cumulativeGasUsed'")
 	}
 	r.CumulativeGasUsed = uint64(*rr.CumulativeGasUsed)
-	r.Bloom = *rr.Bloom
+	if rr.Bloom != nil {
+		r.Bloom = *rr.Bloom
+	}
 	if rr.Logs == nil {
 		return errors.New("missing 'logs'")
 	}
@@ -322,6 +381,13 @@ func (rr *rpcReceipt) copyTo(r *Receipt) error {
 	if rr.To != nil {
 		r.To = rr.To
 	}
+	r.RevertReason = rr.RevertReason
+	if rr.Type != nil {
+		r.Type = uint64(*rr.Type)
+	}
+	if rr.EffectiveGasPrice != nil {
+		r.EffectiveGasPrice = rr.EffectiveGasPrice.ToInt()
+	}
 	return nil
 }
 
@@ -336,8 +402,106 @@ func (rr *rpcReceipt) copyFrom(r *Receipt) {
 	rr.ContractAddress = &r.ContractAddress
 	rr.GasUsed = (*hexutil.Uint64)(&r.GasUsed)
 	rr.ParsedLogs = &r.ParsedLogs
+	rr.RevertReason = r.RevertReason
 	rr.BlockHash = &r.BlockHash
 	rr.BlockNumber = (*hexutil.Uint64)(&r.BlockNumber)
 	rr.From = &r.From
 	rr.To = r.To
+	rr.Type = (*hexutil.Uint64)(&r.Type)
+	rr.EffectiveGasPrice = (*hexutil.Big)(r.EffectiveGasPrice)
+}
+
+type rpcWithdrawal struct {
+	Index          *hexutil.Uint64 `json:"index"`
+	ValidatorIndex *hexutil.Uint64 `json:"validatorIndex"`
+	Address        *common.Address `json:"address"`
+	Amount         *hexutil.Uint64 `json:"amount"`
+}
+
+// copyTo copies the fields from r to w.
+func (r *rpcWithdrawal) copyTo(w *Withdrawal) error {
+	if r.Index == nil {
+		return errors.New("missing 'index'")
+	}
+	w.Index = uint64(*r.Index)
+	if r.ValidatorIndex == nil {
+		return errors.New("missing 'validatorIndex'")
+	}
+	w.ValidatorIndex = uint64(*r.ValidatorIndex)
+	if r.Address == nil {
+		return errors.New("missing 'address'")
+	}
+	w.Address = *r.Address
+	if r.Amount == nil {
+		return errors.New("missing 'amount'")
+	}
+	w.Amount = uint64(*r.Amount)
+	return nil
+}
+
+// copyFrom copies the fields from w to r.
+func (r *rpcWithdrawal) copyFrom(w *Withdrawal) {
+	r.Index = (*hexutil.Uint64)(&w.Index)
+	r.ValidatorIndex = (*hexutil.Uint64)(&w.ValidatorIndex)
+	r.Address = &w.Address
+	r.Amount = (*hexutil.Uint64)(&w.Amount)
+}
+
+type rpcOverrideAccount struct {
+	Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes              `json:"code,omitempty"`
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	State     map[common.Hash]common.Hash `json:"state,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+// copyTo copies the fields from r to o.
+func (r *rpcOverrideAccount) copyTo(o *OverrideAccount) {
+	if r.Nonce != nil {
+		n := uint64(*r.Nonce)
+		o.Nonce = &n
+	}
+	if r.Code != nil {
+		o.Code = *r.Code
+	}
+	if r.Balance != nil {
+		o.Balance = r.Balance.ToInt()
+	}
+	o.State = r.State
+	o.StateDiff = r.StateDiff
+}
+
+// copyFrom copies the fields from o to r.
+func (r *rpcOverrideAccount) copyFrom(o *OverrideAccount) {
+	r.Nonce = (*hexutil.Uint64)(o.Nonce)
+	if o.Code != nil {
+		r.Code = (*hexutil.Bytes)(&o.Code)
+	}
+	r.Balance = (*hexutil.Big)(o.Balance)
+	r.State = o.State
+	r.StateDiff = o.StateDiff
+}
+
+type rpcFeeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// copyTo copies the fields from r to f.
+func (r *rpcFeeHistory) copyTo(f *FeeHistory) {
+	f.OldestBlock = (*big.Int)(r.OldestBlock)
+	f.Reward = make([][]*big.Int, len(r.Reward))
+	for i, rewards := range r.Reward {
+		f.Reward[i] = make([]*big.Int, len(rewards))
+		for j, reward := range rewards {
+			f.Reward[i][j] = (*big.Int)(reward)
+		}
+	}
+	f.BaseFee = make([]*big.Int, len(r.BaseFee))
+	for i, fee := range r.BaseFee {
+		f.BaseFee[i] = (*big.Int)(fee)
+	}
+	f.GasUsedRatio = r.GasUsedRatio
 }
@@ -0,0 +1,500 @@
+package web3
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/core/types"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/web3/assets"
+)
+
+// SignatureDB maps function selectors and event topics to text signatures like
+// "transfer(address,uint256)", for decoding calls and logs when the contract ABI is unknown.
+// Different signatures may share a selector, so lookups return every candidate.
+type SignatureDB struct {
+	mu        sync.RWMutex
+	functions map[[4]byte][]string
+	events    map[common.Hash][]string
+}
+
+// NewSignatureDB returns an empty signature database.
+func NewSignatureDB() *SignatureDB {
+	return &SignatureDB{
+		functions: map[[4]byte][]string{},
+		events:    map[common.Hash][]string{},
+	}
+}
+
+var (
+	defaultSignaturesOnce sync.Once
+	defaultSignatures     *SignatureDB
+)
+
+// DefaultSignatures returns the shared signature database, seeded from the bundled contract ABIs
+// and common standards.
+func DefaultSignatures() *SignatureDB {
+	defaultSignaturesOnce.Do(func() {
+		db := NewSignatureDB()
+		for _, s := range []string{
+			assets.ERC20ABI,
+			assets.ERC721ABI,
+			assets.ERC1155ABI,
+			assets.ERC4626ABI,
+			assets.ERC2612ABI,
+			assets.UpgradeableProxyABI,
+			assets.OwnerUpgradeableProxyABI,
+			assets.TransparentProxyABI,
+			assets.DIDRegistryABI,
+			assets.CommonSignatures,
+		} {
+			if _, err := db.Import(strings.NewReader(s)); err != nil {
+				panic(fmt.Sprintf("invalid bundled signatures: %v", err))
+			}
+		}
+		defaultSignatures = db
+	})
+	return defaultSignatures
+}
+
+// AddFunction adds a function signature, returning false if it was already known.
+func (db *SignatureDB) AddFunction(sig string) (bool, error) {
+	name, args, err := parseSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	sig = signature(name, args)
+	var id [4]byte
+	copy(id[:], crypto.Keccak256([]byte(sig)))
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var added bool
+	db.functions[id], added = appendUnique(db.functions[id], sig)
+	return added, nil
+}
+
+// AddEvent adds an event signature, returning false if it was already known.
+func (db *SignatureDB) AddEvent(sig string) (bool, error) {
+	name, args, err := parseSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	sig = signature(name, args)
+	topic := crypto.Keccak256Hash([]byte(sig))
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var added bool
+	db.events[topic], added = appendUnique(db.events[topic], sig)
+	return added, nil
+}
+
+func appendUnique(sigs []string, sig string) ([]string, bool) {
+	if containsString(sigs, sig) {
+		return sigs, false
+	}
+	return append(sigs, sig), true
+}
+
+// AddABI adds the signatures of all functions and events in myabi, returning the number which were new.
+func (db *SignatureDB) AddABI(myabi abi.ABI) int {
+	var added int
+	for _, m := range myabi.Methods {
+		if ok, _ := db.AddFunction(m.Sig); ok {
+			added++
+		}
+	}
+	for _, e := range myabi.Events {
+		if ok, _ := db.AddEvent(e.Sig); ok {
+			added++
+		}
+	}
+	return added
+}
+
+// Import adds signatures from r, which is either a JSON ABI or a list of signatures, one per line,
+// prefixed with "function" or "event" (functions are assumed when there is no prefix).
+// Blank lines and lines starting with '#' are ignored. It returns the number of new signatures.
+func (db *SignatureDB) Import(r io.Reader) (int, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if b = bytes.TrimSpace(b); bytes.HasPrefix(b, []byte("[")) {
+		b, _, err = splitABIErrors(b)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ABI: %v", err)
+		}
+		myabi, err := abi.JSON(bytes.NewReader(b))
+		if err != nil {
+			return 0, fmt.Errorf("invalid ABI: %v", err)
+		}
+		return db.AddABI(myabi), nil
+	}
+	var added int
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		add := db.AddFunction
+		if sig := strings.TrimPrefix(line, "event "); sig != line {
+			line, add = sig, db.AddEvent
+		} else {
+			line = strings.TrimPrefix(line, "function ")
+		}
+		ok, err := add(strings.TrimSpace(line))
+		if err != nil {
+			return added, fmt.Errorf("line %d: %v", n, err)
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, s.Err()
+}
+
+// Export writes all signatures in the format read by Import.
+func (db *SignatureDB) Export(w io.Writer) error {
+	db.mu.RLock()
+	var lines []string
+	for _, sigs := range db.functions {
+		for _, sig := range sigs {
+			lines = append(lines, "function "+sig)
+		}
+	}
+	for _, sigs := range db.events {
+		for _, sig := range sigs {
+			lines = append(lines, "event "+sig)
+		}
+	}
+	db.mu.RUnlock()
+	sort.Strings(lines)
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of signatures in db.
+func (db *SignatureDB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	var n int
+	for _, sigs := range db.functions {
+		n += len(sigs)
+	}
+	for _, sigs := range db.events {
+		n += len(sigs)
+	}
+	return n
+}
+
+// Subtract returns a new database of the signatures in db which aren't in other.
+func (db *SignatureDB) Subtract(other *SignatureDB) *SignatureDB {
+	diff := NewSignatureDB()
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	for id, sigs := range db.functions {
+		for _, sig := range sigs {
+			if !containsString(other.functions[id], sig) {
+				diff.functions[id] = append(diff.functions[id], sig)
+			}
+		}
+	}
+	for topic, sigs := range db.events {
+		for _, sig := range sigs {
+			if !containsString(other.events[topic], sig) {
+				diff.events[topic] = append(diff.events[topic], sig)
+			}
+		}
+	}
+	return diff
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Functions returns the function signatures with the given 4 byte selector.
+func (db *SignatureDB) Functions(selector []byte) []string {
+	var id [4]byte
+	if len(selector) != len(id) {
+		return nil
+	}
+	copy(id[:], selector)
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]string(nil), db.functions[id]...)
+}
+
+// Events returns the event signatures with the given topic.
+func (db *SignatureDB) Events(topic common.Hash) []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]string(nil), db.events[topic]...)
+}
+
+// DecodeCallData decodes the input of a function call, like DecodeCallData, using the first known
+// signature for its selector which the input is a valid encoding of. Arguments are unnamed.
+func (db *SignatureDB) DecodeCallData(input []byte) (*CallData, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("input too short (%d bytes) for a function call", len(input))
+	}
+	sigs := db.Functions(input[:4])
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("unknown function selector %s", hexutil.Encode(input[:4]))
+	}
+	for _, sig := range sigs {
+		name, args, err := parseSignature(sig)
+		if err != nil {
+			continue
+		}
+		vals, err := unpackExact(args, input[4:])
+		if err != nil {
+			continue
+		}
+		return &CallData{Method: name, Sig: sig, Args: callArgs(args, vals)}, nil
+	}
+	return nil, fmt.Errorf("input does not match any known signature for selector %s: %s",
+		hexutil.Encode(input[:4]), strings.Join(sigs, ", "))
+}
+
+// ParseLogs decodes logs, like ParseLogs, using known event signatures. Since signatures don't record
+// which arguments are indexed, the leading arguments are assumed to be, as is usual. Fields are
+// named by argument position. Logs which don't match any known signature are skipped.
+func (db *SignatureDB) ParseLogs(logs []*types.Log) []Event {
+	var events []Event
+	for _, log := range logs {
+		if e, ok := db.ParseLog(log); ok {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// ParseLog decodes a single log like ParseLogs, returning false if it doesn't match any known signature.
+func (db *SignatureDB) ParseLog(log *types.Log) (Event, bool) {
+	if log == nil || len(log.Topics) == 0 {
+		return Event{}, false
+	}
+	for _, sig := range db.Events(log.Topics[0]) {
+		name, args, err := parseSignature(sig)
+		if err != nil || len(log.Topics)-1 > len(args) {
+			continue
+		}
+		for i := range args[:len(log.Topics)-1] {
+			args[i].Indexed = true
+		}
+		e, err := parseEvent(name, args, log.Topics[1:], log, true)
+		if err != nil {
+			continue
+		}
+		return *e, true
+	}
+	return Event{}, false
+}
+
+// unpackExact unpacks data, requiring that it is exactly the encoding of the values.
+func unpackExact(args abi.Arguments, data []byte) ([]interface{}, error) {
+	vals, err := args.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := args.Pack(vals...)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(packed, data) {
+		return nil, errors.New("data is not a canonical encoding")
+	}
+	return vals, nil
+}
+
+// isTopicValueType reports whether an indexed field of type t holds its value in its topic, as value
+// types do, rather than the hash of its encoding.
+func isTopicValueType(t abi.Type) bool {
+	switch t.T {
+	case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy:
+		return true
+	}
+	return false
+}
+
+// signature returns the canonical signature of a function or event.
+func signature(name string, args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(types, ","))
+}
+
+// parseSignature parses a text signature like "transfer(address,uint256)". Parameter names
+// and the "indexed" keyword are allowed, as in "Transfer(address indexed from, address to)",
+// and so are human-readable ABI entries, like "function transfer(address to, uint256 amount)".
+func parseSignature(sig string) (string, abi.Arguments, error) {
+	sig = strings.TrimSpace(sig)
+	if isHumanABI(sig) {
+		e, err := parseHumanABIEntry(sig)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid signature %q: %v", sig, err)
+		}
+		args := make(abi.Arguments, len(e.Inputs))
+		for i, input := range e.Inputs {
+			args[i].Type, err = abi.NewType(input.Type, "", input.Components)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid signature %q: %v", sig, err)
+			}
+			args[i].Name, args[i].Indexed = input.Name, input.Indexed
+		}
+		return e.Name, args, nil
+	}
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("invalid signature %q", sig)
+	}
+	name := strings.TrimSpace(sig[:open])
+	if strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return "", nil, fmt.Errorf("invalid signature %q: invalid name %q", sig, name)
+	}
+	args, err := ParseArguments(sig[open+1 : len(sig)-1])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid signature %q: %v", sig, err)
+	}
+	return name, args, nil
+}
+
+// ParseArguments parses a comma separated list of types, optionally with names, such as
+// "address to, uint256 amount" or "(uint256,bytes)[],bool".
+func ParseArguments(list string) (abi.Arguments, error) {
+	params, err := splitParams(list)
+	if err != nil {
+		return nil, err
+	}
+	args := make(abi.Arguments, len(params))
+	for i, p := range params {
+		m, err := paramMarshaling(p)
+		if err != nil {
+			return nil, err
+		}
+		args[i].Type, err = abi.NewType(m.Type, "", m.Components)
+		if err != nil {
+			return nil, err
+		}
+		args[i].Name, args[i].Indexed = m.Name, m.Indexed
+	}
+	return args, nil
+}
+
+// splitParams splits a parameter list on the commas which aren't nested in a tuple.
+func splitParams(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var params []string
+	var depth, start int
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	return append(params, strings.TrimSpace(s[start:])), nil
+}
+
+// paramMarshaling parses a parameter like "address indexed from" or "(uint256 id, bytes data)[] calls",
+// with tuple components expanded. Data location and payable keywords are ignored.
+func paramMarshaling(p string) (abi.ArgumentMarshaling, error) {
+	var m abi.ArgumentMarshaling
+	var fields []string
+	if p = strings.TrimPrefix(strings.TrimSpace(p), "tuple"); strings.HasPrefix(p, "(") {
+		end := strings.LastIndex(p, ")")
+		params, err := splitParams(p[1:end])
+		if err != nil {
+			return m, err
+		}
+		for i, cp := range params {
+			c, err := paramMarshaling(cp)
+			if err != nil {
+				return m, err
+			}
+			if c.Name == "" {
+				// Components need distinct names to be unpacked into a struct.
+				c.Name = fmt.Sprintf("field%d", i)
+			}
+			m.Components = append(m.Components, c)
+		}
+		m.Type = "tuple"
+		fields = strings.Fields(p[end+1:])
+		if len(fields) > 0 && strings.HasPrefix(fields[0], "[") {
+			m.Type += fields[0]
+			fields = fields[1:]
+		}
+	} else {
+		fields = strings.Fields(p)
+		if len(fields) == 0 {
+			return m, errors.New("empty parameter")
+		}
+		m.Type = canonicalType(fields[0])
+		fields = fields[1:]
+	}
+	for _, f := range fields {
+		switch f {
+		case "indexed":
+			m.Indexed = true
+		case "memory", "calldata", "storage", "payable":
+		default:
+			if m.Name != "" {
+				return m, fmt.Errorf("unexpected %q in parameter %q", f, p)
+			}
+			m.Name = f
+		}
+	}
+	return m, nil
+}
+
+// canonicalType expands the uint and int aliases, as in "uint[]" to "uint256[]".
+func canonicalType(t string) string {
+	base, suffix := t, ""
+	if i := strings.Index(t, "["); i >= 0 {
+		base, suffix = t[:i], t[i:]
+	}
+	if base == "uint" || base == "int" {
+		return base + "256" + suffix
+	}
+	return t
+}
@@ -0,0 +1,95 @@
+package web3
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/core/types"
+	"github.com/gochain/gochain/v4/crypto"
+)
+
+func TestDefaultSignatures(t *testing.T) {
+	db := DefaultSignatures()
+	if got := db.Functions(hexutil.MustDecode("0xa9059cbb")); !reflect.DeepEqual(got, []string{"transfer(address,uint256)"}) {
+		t.Errorf("unexpected transfer signatures: %v", got)
+	}
+	topic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	if got := db.Events(topic); !reflect.DeepEqual(got, []string{"Transfer(address,address,uint256)"}) {
+		t.Errorf("unexpected Transfer signatures: %v", got)
+	}
+
+	from, to := common.Address{19: 1}, common.Address{19: 2}
+	log := &types.Log{
+		Topics: []common.Hash{topic, from.Hash(), to.Hash()},
+		Data:   common.LeftPadBytes(big.NewInt(7).Bytes(), 32),
+	}
+	e, ok := db.ParseLog(log)
+	if !ok {
+		t.Fatal("expected ERC-20 transfer log to be parsed")
+	}
+	if e.Name != "Transfer" || e.Fields["0"] != from || e.Fields["1"] != to || e.Fields["2"].(*big.Int).Int64() != 7 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestSignatureDB_Import(t *testing.T) {
+	db := NewSignatureDB()
+	n, err := db.Import(strings.NewReader(`
+# comment
+function submit(uint256 id, (address to, bytes data)[] calls)
+event Submitted(uint256 indexed id)
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 signatures but got %d", n)
+	}
+	if _, err := db.Import(strings.NewReader("function broken(uint256")); err == nil {
+		t.Error("expected error for invalid signature")
+	}
+
+	const sig = "submit(uint256,(address,bytes)[])"
+	input := append(crypto.Keccak256([]byte(sig))[:4], hexutil.MustDecode("0x"+
+		"0000000000000000000000000000000000000000000000000000000000000001"+
+		"0000000000000000000000000000000000000000000000000000000000000040"+
+		"0000000000000000000000000000000000000000000000000000000000000000")...)
+	cd, err := db.DecodeCallData(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cd.Sig != sig || len(cd.Args) != 2 || cd.Args[0].Value.(*big.Int).Int64() != 1 {
+		t.Errorf("unexpected call data: %+v", cd)
+	}
+	if _, err := db.DecodeCallData(input[:len(input)-1]); err == nil {
+		t.Error("expected error for truncated input")
+	}
+}
+
+func TestSignatureDB_Subtract(t *testing.T) {
+	existing, imported := NewSignatureDB(), NewSignatureDB()
+	if _, err := existing.Import(strings.NewReader("transfer(address,uint256)\nevent Foo(uint256)")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := imported.Import(strings.NewReader("transfer(address,uint256)\nevent Foo(uint256)\nbar()\nevent Bar()")); err != nil {
+		t.Fatal(err)
+	}
+	added := imported.Subtract(existing)
+	if n := added.Len(); n != 2 {
+		t.Errorf("expected 2 new signatures but got %d", n)
+	}
+	var b strings.Builder
+	if err := added.Export(&b); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "event Bar()\nfunction bar()\n"; b.String() != exp {
+		t.Errorf("expected new signatures %q but got %q", exp, b.String())
+	}
+	if n := existing.Subtract(imported).Len(); n != 0 {
+		t.Errorf("expected no new signatures but got %d", n)
+	}
+}
@@ -0,0 +1,347 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts"
+	"github.com/gochain/gochain/v4/accounts/keystore"
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/core/types"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/gochain/v4/rlp"
+	"github.com/gochain/gochain/v4/rpc"
+)
+
+// Signer signs transactions and messages for an account, without exposing its private key.
+// Account, KeystoreSigner and RemoteSigner are Signers.
+type Signer interface {
+	// Address returns the address of the signing account.
+	Address() common.Address
+	// SignTx signs tx, which must have its ChainID set, filling in its signature values, From and Hash.
+	// tx.Type selects a legacy (0) or dynamic fee (DynamicFeeTxType) transaction.
+	// It returns the raw transaction for SendRawTransaction.
+	SignTx(ctx context.Context, tx *Transaction) ([]byte, error)
+	// SignHash signs a 32 byte hash, returning a 65 byte [R || S || V] signature, where V is 0 or 1.
+	SignHash(ctx context.Context, hash common.Hash) ([]byte, error)
+	// SignTypedData signs EIP-712 typed data, returning a 65 byte [R || S || V] signature,
+	// where V is 27 or 28, as returned by eth_signTypedData_v4.
+	SignTypedData(ctx context.Context, data *TypedData) ([]byte, error)
+}
+
+// SignTx implements Signer.
+func (a *Account) SignTx(ctx context.Context, tx *Transaction) ([]byte, error) {
+	return signTransaction(tx, a.Address(), func(h common.Hash) ([]byte, error) {
+		return crypto.Sign(h[:], a.key)
+	})
+}
+
+// SignHash implements Signer.
+func (a *Account) SignHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return crypto.Sign(hash[:], a.key)
+}
+
+// SignTypedData implements Signer.
+func (a *Account) SignTypedData(ctx context.Context, data *TypedData) ([]byte, error) {
+	return signTypedData(ctx, a, data)
+}
+
+// KeystoreSigner signs with an account from an encrypted keystore directory, as written by geth
+// and `web3 account new`. The key is decrypted once, when the signer is created.
+type KeystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+// NewKeystoreSigner returns a signer for the from account in the keystore directory keydir,
+// unlocking it with password.
+func NewKeystoreSigner(keydir string, from common.Address, password string) (*KeystoreSigner, error) {
+	ks := keystore.NewKeyStore(keydir, keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.Find(accounts.Account{Address: from})
+	if err != nil {
+		return nil, fmt.Errorf("cannot find %s in keystore %q: %v", from.Hex(), keydir, err)
+	}
+	if err := ks.Unlock(account, password); err != nil {
+		return nil, fmt.Errorf("cannot unlock %s: %v", from.Hex(), err)
+	}
+	return &KeystoreSigner{ks: ks, account: account}, nil
+}
+
+// Address implements Signer.
+func (s *KeystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignTx implements Signer.
+func (s *KeystoreSigner) SignTx(ctx context.Context, tx *Transaction) ([]byte, error) {
+	return signTransaction(tx, s.account.Address, func(h common.Hash) ([]byte, error) {
+		return s.ks.SignHash(s.account, h[:])
+	})
+}
+
+// SignHash implements Signer.
+func (s *KeystoreSigner) SignHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return s.ks.SignHash(s.account, hash[:])
+}
+
+// SignTypedData implements Signer.
+func (s *KeystoreSigner) SignTypedData(ctx context.Context, data *TypedData) ([]byte, error) {
+	return signTypedData(ctx, s, data)
+}
+
+// Lock removes the decrypted key from memory. The signer can't be used afterwards.
+func (s *KeystoreSigner) Lock() error {
+	return s.ks.Lock(s.account.Address)
+}
+
+// RemoteSigner signs with an account held by a JSON-RPC signer, such as Clef or a node with
+// unlocked accounts, using eth_signTransaction and eth_signTypedData_v4.
+// Remote signers don't sign raw hashes, so SignHash always fails.
+type RemoteSigner struct {
+	r       *rpc.Client
+	address common.Address
+}
+
+// DialRemoteSigner returns a signer for the from account of the JSON-RPC signer at url.
+func DialRemoteSigner(url string, from common.Address) (*RemoteSigner, error) {
+	r, err := rpc.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteSigner(r, from), nil
+}
+
+// NewRemoteSigner returns a signer for the from account of the JSON-RPC signer r.
+func NewRemoteSigner(r *rpc.Client, from common.Address) *RemoteSigner {
+	return &RemoteSigner{r: r, address: from}
+}
+
+// Address implements Signer.
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer.
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *Transaction) ([]byte, error) {
+	if tx.ChainID == nil {
+		return nil, errors.New("missing chain ID")
+	}
+	args := map[string]interface{}{
+		"from":    s.address,
+		"gas":     hexutil.Uint64(tx.GasLimit),
+		"nonce":   hexutil.Uint64(tx.Nonce),
+		"data":    hexutil.Bytes(tx.Input),
+		"chainId": (*hexutil.Big)(tx.ChainID),
+	}
+	if tx.To != nil {
+		args["to"] = tx.To
+	}
+	if tx.Value != nil {
+		args["value"] = (*hexutil.Big)(tx.Value)
+	}
+	switch tx.Type {
+	case 0:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice)
+	case DynamicFeeTxType:
+		args["type"] = hexutil.Uint64(tx.Type)
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.MaxFeePerGas)
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.MaxPriorityFeePerGas)
+		if len(tx.AccessList) > 0 {
+			args["accessList"] = tx.AccessList
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type)
+	}
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.r.CallContext(ctx, &result, "eth_signTransaction", args); err != nil {
+		return nil, err
+	}
+	if len(result.Raw) == 0 {
+		return nil, errors.New("signer returned no transaction")
+	}
+	// Only the raw transaction is signed, so check it rather than trusting the signer's decoding.
+	signed, err := DecodeRawTransaction(result.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("signer returned an invalid transaction: %v", err)
+	}
+	if err := checkSignedTx(tx, signed, s.address); err != nil {
+		return nil, err
+	}
+	tx.V, tx.R, tx.S, tx.YParity = signed.V, signed.R, signed.S, signed.YParity
+	tx.From = signed.From
+	tx.Hash = signed.Hash
+	return result.Raw, nil
+}
+
+// checkSignedTx returns an error if signed, decoded from a remote signer's raw transaction,
+// isn't tx signed by from.
+func checkSignedTx(tx, signed *Transaction, from common.Address) error {
+	var diff []string
+	check := func(field string, equal bool) {
+		if !equal {
+			diff = append(diff, field)
+		}
+	}
+	check("type", signed.Type == tx.Type)
+	check("chain ID", bigEqual(signed.ChainID, tx.ChainID))
+	check("nonce", signed.Nonce == tx.Nonce)
+	check("gas limit", signed.GasLimit == tx.GasLimit)
+	check("to", (signed.To == nil && tx.To == nil) || (signed.To != nil && tx.To != nil && *signed.To == *tx.To))
+	check("value", bigEqual(signed.Value, tx.Value))
+	check("data", bytes.Equal(signed.Input, tx.Input))
+	if tx.Type == DynamicFeeTxType {
+		check("max fee", bigEqual(signed.MaxFeePerGas, tx.MaxFeePerGas))
+		check("max priority fee", bigEqual(signed.MaxPriorityFeePerGas, tx.MaxPriorityFeePerGas))
+		check("access list", len(signed.AccessList) == len(tx.AccessList) &&
+			(len(tx.AccessList) == 0 || reflect.DeepEqual(signed.AccessList, tx.AccessList)))
+	} else {
+		check("gas price", bigEqual(signed.GasPrice, tx.GasPrice))
+	}
+	check("sender", signed.From == from)
+	if len(diff) > 0 {
+		return fmt.Errorf("signer returned a transaction with a different %s", strings.Join(diff, ", "))
+	}
+	return nil
+}
+
+// bigEqual reports whether a and b are equal, treating nil as zero.
+func bigEqual(a, b *big.Int) bool {
+	if a == nil {
+		a = new(big.Int)
+	}
+	if b == nil {
+		b = new(big.Int)
+	}
+	return a.Cmp(b) == 0
+}
+
+// SignHash implements Signer, but always fails, since remote signers only sign transactions and messages.
+func (s *RemoteSigner) SignHash(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return nil, errors.New("remote signers can't sign raw hashes")
+}
+
+// SignTypedData implements Signer.
+func (s *RemoteSigner) SignTypedData(ctx context.Context, data *TypedData) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := s.r.CallContext(ctx, &sig, "eth_signTypedData_v4", s.address, data); err != nil {
+		return nil, err
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("wrong size for signature: got %d, want %d", len(sig), crypto.SignatureLength)
+	}
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	return sig, nil
+}
+
+// Close closes the connection to the signer.
+func (s *RemoteSigner) Close() {
+	s.r.Close()
+}
+
+// SignerPublicKey returns the public key of signer, recovering it from a signature unless signer is an Account.
+func SignerPublicKey(ctx context.Context, signer Signer) (*ecdsa.PublicKey, error) {
+	if a, ok := signer.(*Account); ok {
+		return &a.key.PublicKey, nil
+	}
+	h := crypto.Keccak256Hash(signer.Address().Bytes())
+	sig, err := signer.SignHash(ctx, h)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sign to recover public key: %v", err)
+	}
+	pub, err := crypto.SigToPub(h[:], sig)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(*pub) != signer.Address() {
+		return nil, errors.New("signature does not match the signer's address")
+	}
+	return pub, nil
+}
+
+// signTypedData signs the EIP-712 hash of data with signer, adjusting V to 27 or 28.
+func signTypedData(ctx context.Context, signer Signer, data *TypedData) ([]byte, error) {
+	h, err := data.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer.SignHash(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// signTransaction signs tx from address from, with sign returning a [R || S || V] signature of a hash,
+// and returns the raw transaction.
+func signTransaction(tx *Transaction, from common.Address, sign func(common.Hash) ([]byte, error)) ([]byte, error) {
+	if tx.ChainID == nil {
+		return nil, errors.New("missing chain ID")
+	}
+	switch tx.Type {
+	case 0:
+	case DynamicFeeTxType:
+		dtx := &DynamicFeeTx{
+			ChainID:              tx.ChainID,
+			Nonce:                tx.Nonce,
+			MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+			MaxFeePerGas:         tx.MaxFeePerGas,
+			Gas:                  tx.GasLimit,
+			To:                   tx.To,
+			Value:                tx.Value,
+			Data:                 tx.Input,
+			AccessList:           tx.AccessList,
+		}
+		h, err := dtx.SigningHash()
+		if err != nil {
+			return nil, err
+		}
+		sig, err := sign(h)
+		if err != nil {
+			return nil, err
+		}
+		if err := dtx.SetSignature(sig); err != nil {
+			return nil, err
+		}
+		raw, err := dtx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		*tx = *convertDynamicFeeTx(dtx, crypto.Keccak256Hash(raw), from)
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type)
+	}
+	var ltx *types.Transaction
+	if tx.To == nil {
+		ltx = types.NewContractCreation(tx.Nonce, tx.Value, tx.GasLimit, tx.GasPrice, tx.Input)
+	} else {
+		ltx = types.NewTransaction(tx.Nonce, *tx.To, tx.Value, tx.GasLimit, tx.GasPrice, tx.Input)
+	}
+	signer := types.NewEIP155Signer(tx.ChainID)
+	sig, err := sign(signer.Hash(ltx))
+	if err != nil {
+		return nil, err
+	}
+	signed, err := ltx.WithSignature(signer, sig)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	*tx = *convertTx(signed, from)
+	return raw, nil
+}
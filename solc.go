@@ -30,17 +30,18 @@ type Contract struct {
 // Depending on the source, language version, compiler version, and compiler
 // options will provide information about how the contract was compiled.
 type ContractInfo struct {
-	Source          string      `json:"source"`
-	Language        string      `json:"language"`
-	LanguageVersion string      `json:"languageVersion"`
-	CompilerVersion string      `json:"compilerVersion"`
-	CompilerOptions string      `json:"compilerOptions"`
-	SrcMap          interface{} `json:"srcMap"`
-	SrcMapRuntime   string      `json:"srcMapRuntime"`
-	AbiDefinition   interface{} `json:"abiDefinition"`
-	UserDoc         interface{} `json:"userDoc"`
-	DeveloperDoc    interface{} `json:"developerDoc"`
-	Metadata        string      `json:"metadata"`
+	Source          string         `json:"source"`
+	Language        string         `json:"language"`
+	LanguageVersion string         `json:"languageVersion"`
+	CompilerVersion string         `json:"compilerVersion"`
+	CompilerOptions string         `json:"compilerOptions"`
+	SrcMap          interface{}    `json:"srcMap"`
+	SrcMapRuntime   string         `json:"srcMapRuntime"`
+	AbiDefinition   interface{}    `json:"abiDefinition"`
+	UserDoc         interface{}    `json:"userDoc"`
+	DeveloperDoc    interface{}    `json:"developerDoc"`
+	Metadata        string         `json:"metadata"`
+	StorageLayout   *StorageLayout `json:"storageLayout,omitempty"`
 }
 
 // Solidity specifies the solidity compiler configuration.
@@ -48,6 +49,7 @@ type Solidity struct {
 	Path, Version, EVMVersion string
 	Major, Minor, Patch       int
 	Optimize                  bool
+	StorageLayout             bool // include the storage layout, which older compilers don't support
 }
 
 // --combined-output format
@@ -56,6 +58,7 @@ type solcOutput struct {
 		BinRuntime                                  string `json:"bin-runtime"`
 		SrcMapRuntime                               string `json:"srcmap-runtime"`
 		Bin, SrcMap, Abi, Devdoc, Userdoc, Metadata string
+		StorageLayout                               json.RawMessage `json:"storage-layout"`
 	}
 	Version string
 }
@@ -68,6 +71,7 @@ type solcOutputV8 struct {
 		Devdoc                interface{}
 		Userdoc               interface{}
 		Hashes                map[string]string
+		StorageLayout         json.RawMessage `json:"storage-layout"`
 	}
 	Version string
 }
@@ -77,10 +81,13 @@ func (s *Solidity) makeArgs() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	combined := "bin,bin-runtime,srcmap,srcmap-runtime,abi,userdoc,devdoc,metadata"
+	if s.StorageLayout {
+		combined += ",storage-layout"
+	}
 	args := []string{
 		"run", "-i", "--rm", "-v", dir + ":/workdir", "-w", "/workdir", "ethereum/solc:" + s.Version,
-		"--combined-json",
-		"bin,bin-runtime,srcmap,srcmap-runtime,abi,userdoc,devdoc,metadata",
+		"--combined-json", combined,
 		"--evm-version", s.EVMVersion,
 	}
 	if s.Optimize {
@@ -139,6 +146,17 @@ func CompileSolidityString(ctx context.Context, source, solcVersion, evmVersion
 	// fmt.Printf("Building with solidity version %v\n", s.Version)
 	s.EVMVersion = evmVersion
 	s.Optimize = optimize
+	s.StorageLayout = true
+	contracts, err := s.compile(ctx, source)
+	if err != nil && strings.Contains(err.Error(), "storage-layout") {
+		// Older compilers can't output the storage layout.
+		s.StorageLayout = false
+		contracts, err = s.compile(ctx, source)
+	}
+	return contracts, err
+}
+
+func (s *Solidity) compile(ctx context.Context, source string) (map[string]*Contract, error) {
 	args, err := s.makeArgs()
 	if err != nil {
 		return nil, err
@@ -186,6 +204,10 @@ func ParseCombinedJSON(combinedJSON []byte, source string, languageVersion strin
 		if err := json.Unmarshal([]byte(info.Devdoc), &devdoc); err != nil {
 			return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
 		}
+		layout, err := parseStorageLayout(info.StorageLayout)
+		if err != nil {
+			return nil, err
+		}
 		contracts[name] = &Contract{
 			Code:        "0x" + info.Bin,
 			RuntimeCode: "0x" + info.BinRuntime,
@@ -201,6 +223,7 @@ func ParseCombinedJSON(combinedJSON []byte, source string, languageVersion strin
 				UserDoc:         userdoc,
 				DeveloperDoc:    devdoc,
 				Metadata:        info.Metadata,
+				StorageLayout:   layout,
 			},
 		}
 	}
@@ -217,6 +240,10 @@ func parseCombinedJSONV8(combinedJSON []byte, source string, languageVersion str
 	// Compilation succeeded, assemble and return the contracts.
 	contracts := make(map[string]*Contract)
 	for name, info := range output.Contracts {
+		layout, err := parseStorageLayout(info.StorageLayout)
+		if err != nil {
+			return nil, err
+		}
 		contracts[name] = &Contract{
 			Code:        "0x" + info.Bin,
 			RuntimeCode: "0x" + info.BinRuntime,
@@ -233,8 +260,25 @@ func parseCombinedJSONV8(combinedJSON []byte, source string, languageVersion str
 				UserDoc:         info.Userdoc,
 				DeveloperDoc:    info.Devdoc,
 				Metadata:        info.Metadata,
+				StorageLayout:   layout,
 			},
 		}
 	}
 	return contracts, nil
 }
+
+// parseStorageLayout parses a storage layout, which older compilers output as a JSON string.
+func parseStorageLayout(raw json.RawMessage) (*StorageLayout, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		raw = json.RawMessage(str)
+	}
+	var layout StorageLayout
+	if err := json.Unmarshal(raw, &layout); err != nil {
+		return nil, fmt.Errorf("solc: error reading storage layout: %v", err)
+	}
+	return &layout, nil
+}
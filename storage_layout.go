@@ -0,0 +1,260 @@
+package web3
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// StorageLayout is the storage layout of a contract, as output by solc.
+type StorageLayout struct {
+	Storage []StorageVariable      `json:"storage"`
+	Types   map[string]StorageType `json:"types"`
+}
+
+// StorageVariable is a state variable, or struct member, in a StorageLayout.
+type StorageVariable struct {
+	AstID    int    `json:"astId"`
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"` // in bytes, within the slot
+	Slot     string `json:"slot"`   // decimal
+	Type     string `json:"type"`   // key in StorageLayout.Types
+}
+
+// StorageType describes a type in a StorageLayout.
+type StorageType struct {
+	Encoding      string            `json:"encoding"` // inplace, mapping, dynamic_array or bytes
+	Label         string            `json:"label"`
+	NumberOfBytes string            `json:"numberOfBytes"`
+	Base          string            `json:"base,omitempty"`
+	Key           string            `json:"key,omitempty"`
+	Value         string            `json:"value,omitempty"`
+	Members       []StorageVariable `json:"members,omitempty"`
+}
+
+// ReadStorageLayout reads a storage layout JSON file, such as written by `web3 contract build`.
+func ReadStorageLayout(filename string) (*StorageLayout, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var layout StorageLayout
+	if err := json.Unmarshal(b, &layout); err != nil {
+		return nil, fmt.Errorf("invalid storage layout %q: %v", filename, err)
+	}
+	return &layout, nil
+}
+
+// StorageChange is a difference between two versions of a contract's storage layout.
+type StorageChange struct {
+	Label    string `json:"label"`
+	Slot     string `json:"slot"`
+	Offset   int    `json:"offset"`
+	Change   string `json:"change"` // added, inserted, removed, renamed, reordered, retyped or shrunk
+	Detail   string `json:"detail,omitempty"`
+	Breaking bool   `json:"breaking"` // whether the new contract would misread existing storage
+}
+
+func (c StorageChange) String() string {
+	class := "additive"
+	if c.Breaking {
+		class = "BREAKING"
+	}
+	s := fmt.Sprintf("%-8s %s %s (slot %s, offset %d)", class, c.Change, c.Label, c.Slot, c.Offset)
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// BreakingStorageChanges reports whether any of changes is breaking.
+func BreakingStorageChanges(changes []StorageChange) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffStorageLayout compares the storage layouts of an old and new implementation of an upgradeable
+// contract. Since the new implementation reads the storage written by the old one, its variables must
+// keep the slots, offsets and types of the old ones, and new variables may only be appended.
+// Removed, reordered, retyped and inserted variables are breaking, while renamed and appended ones aren't.
+// Storage gaps, fixed size arrays named __gap which reserve slots for later versions, may shrink from
+// either end to make room for new variables.
+func DiffStorageLayout(oldLayout, newLayout *StorageLayout) []StorageChange {
+	var changes []StorageChange
+	oldLabels, newLabels := map[string]bool{}, map[string]bool{}
+	for _, v := range oldLayout.Storage {
+		oldLabels[v.Label] = true
+	}
+	for _, v := range newLayout.Storage {
+		newLabels[v.Label] = true
+	}
+	newByPos := map[string]StorageVariable{}
+	for _, v := range newLayout.Storage {
+		newByPos[storagePos(v)] = v
+	}
+	renamed := map[string]bool{}
+	var gaps []StorageVariable // old gaps which have shrunk
+	oldEnd := new(big.Int)
+	for _, o := range oldLayout.Storage {
+		if end := storageEnd(oldLayout, o); end.Cmp(oldEnd) > 0 {
+			oldEnd = end
+		}
+		ot := storageTypeString(oldLayout, o.Type, nil)
+		if n, ok := shrunkStorageGap(oldLayout, newLayout, o); ok {
+			if nt := storageTypeString(newLayout, n.Type, nil); ot != nt || storagePos(o) != storagePos(n) {
+				gaps = append(gaps, o)
+				changes = append(changes, StorageChange{Label: o.Label, Slot: o.Slot, Offset: o.Offset, Change: "shrunk",
+					Detail: fmt.Sprintf("type changed from %s to %s at slot %s", ot, nt, n.Slot)})
+			}
+			continue
+		}
+		n, ok := newByPos[storagePos(o)]
+		if !ok {
+			// Its old value would be left for any new variable overlapping it.
+			c := StorageChange{Label: o.Label, Slot: o.Slot, Offset: o.Offset, Change: "removed", Breaking: true}
+			if newLabels[o.Label] {
+				c.Change, c.Detail = "reordered", "moved to a different slot or offset"
+			}
+			changes = append(changes, c)
+			continue
+		}
+		if o.Label != n.Label && (oldLabels[n.Label] || newLabels[o.Label]) {
+			changes = append(changes, StorageChange{Label: o.Label, Slot: o.Slot, Offset: o.Offset, Change: "reordered", Breaking: true,
+				Detail: fmt.Sprintf("position now holds %s", n.Label)})
+			continue
+		}
+		if nt := storageTypeString(newLayout, n.Type, nil); ot != nt {
+			changes = append(changes, StorageChange{Label: o.Label, Slot: o.Slot, Offset: o.Offset, Change: "retyped", Breaking: true,
+				Detail: fmt.Sprintf("type changed from %s to %s", ot, nt)})
+		} else if o.Label != n.Label {
+			changes = append(changes, StorageChange{Label: o.Label, Slot: o.Slot, Offset: o.Offset, Change: "renamed",
+				Detail: fmt.Sprintf("renamed to %s", n.Label)})
+		}
+		if o.Label != n.Label {
+			renamed[n.Label] = true
+		}
+	}
+	for _, n := range newLayout.Storage {
+		if oldLabels[n.Label] || renamed[n.Label] {
+			// Already compared with the old variable.
+			continue
+		}
+		if storageStart(n).Cmp(oldEnd) >= 0 {
+			changes = append(changes, StorageChange{Label: n.Label, Slot: n.Slot, Offset: n.Offset, Change: "added"})
+		} else if gap, ok := storageGapHolding(oldLayout, newLayout, gaps, n); ok {
+			changes = append(changes, StorageChange{Label: n.Label, Slot: n.Slot, Offset: n.Offset, Change: "added",
+				Detail: "in storage reserved by " + gap.Label})
+		} else {
+			changes = append(changes, StorageChange{Label: n.Label, Slot: n.Slot, Offset: n.Offset, Change: "inserted", Breaking: true,
+				Detail: "overlaps existing storage"})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Breaking && !changes[j].Breaking
+	})
+	return changes
+}
+
+// isStorageGap reports whether v is a storage gap: a fixed size array named __gap, by convention,
+// which reserves slots for variables added by later versions of its contract.
+func isStorageGap(layout *StorageLayout, v StorageVariable) bool {
+	t := layout.Types[v.Type]
+	return strings.HasPrefix(v.Label, "__gap") && t.Encoding == "inplace" && t.Base != ""
+}
+
+// shrunkStorageGap returns the new version of the old storage gap o, if it has the same element type
+// and lies within o's storage.
+func shrunkStorageGap(oldLayout, newLayout *StorageLayout, o StorageVariable) (StorageVariable, bool) {
+	if !isStorageGap(oldLayout, o) {
+		return StorageVariable{}, false
+	}
+	base := storageTypeString(oldLayout, oldLayout.Types[o.Type].Base, nil)
+	for _, n := range newLayout.Storage {
+		if n.Label != o.Label || n.Contract != o.Contract || !isStorageGap(newLayout, n) ||
+			storageTypeString(newLayout, newLayout.Types[n.Type].Base, nil) != base {
+			continue
+		}
+		if storageStart(n).Cmp(storageStart(o)) >= 0 && storageEnd(newLayout, n).Cmp(storageEnd(oldLayout, o)) <= 0 {
+			return n, true
+		}
+	}
+	return StorageVariable{}, false
+}
+
+// storageGapHolding returns the old storage gap, of gaps, whose storage holds the new variable n.
+func storageGapHolding(oldLayout, newLayout *StorageLayout, gaps []StorageVariable, n StorageVariable) (StorageVariable, bool) {
+	for _, g := range gaps {
+		if storageStart(n).Cmp(storageStart(g)) >= 0 && storageEnd(newLayout, n).Cmp(storageEnd(oldLayout, g)) <= 0 {
+			return g, true
+		}
+	}
+	return StorageVariable{}, false
+}
+
+func storagePos(v StorageVariable) string {
+	return fmt.Sprintf("%s:%d", v.Slot, v.Offset)
+}
+
+// storageStart returns the byte position of v's storage.
+func storageStart(v StorageVariable) *big.Int {
+	slot, ok := new(big.Int).SetString(v.Slot, 10)
+	if !ok {
+		slot = new(big.Int)
+	}
+	start := slot.Mul(slot, big.NewInt(32))
+	return start.Add(start, big.NewInt(int64(v.Offset)))
+}
+
+// storageEnd returns the byte position after v's storage.
+func storageEnd(layout *StorageLayout, v StorageVariable) *big.Int {
+	size, ok := new(big.Int).SetString(layout.Types[v.Type].NumberOfBytes, 10)
+	if !ok {
+		size = big.NewInt(32)
+	}
+	return size.Add(size, storageStart(v))
+}
+
+// storageTypeString describes a type without the AST ids in its key, so that it can be compared across
+// compilations. The types and positions of struct members are included, since changing them changes the
+// layout, but not their names, which may be renamed.
+func storageTypeString(layout *StorageLayout, id string, seen map[string]bool) string {
+	t, ok := layout.Types[id]
+	if !ok {
+		return id
+	}
+	switch t.Encoding {
+	case "mapping":
+		return fmt.Sprintf("mapping(%s => %s)", storageTypeString(layout, t.Key, seen), storageTypeString(layout, t.Value, seen))
+	case "dynamic_array":
+		return storageTypeString(layout, t.Base, seen) + "[]"
+	}
+	if i := strings.LastIndex(t.Label, "["); t.Base != "" && i >= 0 {
+		// Fixed size array.
+		return storageTypeString(layout, t.Base, seen) + t.Label[i:]
+	}
+	if len(t.Members) == 0 {
+		return t.Label
+	}
+	if seen[id] {
+		// Recursive struct, through a mapping or dynamic array.
+		return t.Label
+	}
+	if seen == nil {
+		seen = map[string]bool{}
+	}
+	seen[id] = true
+	defer delete(seen, id)
+	members := make([]string, len(t.Members))
+	for i, m := range t.Members {
+		members[i] = fmt.Sprintf("%s@%s:%d", storageTypeString(layout, m.Type, seen), m.Slot, m.Offset)
+	}
+	return fmt.Sprintf("%s{%s}", t.Label, strings.Join(members, ","))
+}
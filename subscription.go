@@ -0,0 +1,134 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gochain/gochain/v4/rpc"
+)
+
+const (
+	resubscribeMinBackoff = time.Second
+	resubscribeMaxBackoff = time.Minute
+)
+
+// Subscription is a live event subscription.
+// Subscriptions require a websocket ("ws" or "wss") connection. If the connection drops,
+// the subscription is automatically re-established, with an exponential backoff between
+// attempts, and delivery continues on the same channel.
+type Subscription interface {
+	// Err receives an error if the subscription ends for any reason other than
+	// Unsubscribe, and is closed by Unsubscribe.
+	Err() <-chan error
+	// Unsubscribe stops delivery and cancels the subscription on the server.
+	// It can safely be called more than once.
+	Unsubscribe()
+}
+
+// subscribeFunc (re)establishes a subscription, delivering to the same channel each time.
+type subscribeFunc func(ctx context.Context) (*rpc.ClientSubscription, error)
+
+// resubscription is a Subscription which re-subscribes when the underlying subscription fails.
+type resubscription struct {
+	ctx       context.Context
+	subscribe subscribeFunc
+	err       chan error
+	unsub     chan struct{}
+	unsubOnce sync.Once
+	done      chan struct{}
+}
+
+// newResubscription establishes the initial subscription, returning any error, and then
+// keeps it alive until ctx is done or Unsubscribe is called.
+func newResubscription(ctx context.Context, subscribe subscribeFunc) (Subscription, error) {
+	sub, err := subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &resubscription{
+		ctx:       ctx,
+		subscribe: subscribe,
+		err:       make(chan error, 1),
+		unsub:     make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.loop(sub)
+	return s, nil
+}
+
+func (s *resubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *resubscription) Unsubscribe() {
+	s.unsubOnce.Do(func() {
+		close(s.unsub)
+		<-s.done
+		close(s.err)
+	})
+}
+
+func (s *resubscription) loop(sub *rpc.ClientSubscription) {
+	defer close(s.done)
+	for {
+		select {
+		case <-s.unsub:
+			sub.Unsubscribe()
+			return
+		case <-s.ctx.Done():
+			sub.Unsubscribe()
+			s.err <- s.ctx.Err()
+			return
+		case err := <-sub.Err():
+			if err == nil {
+				// The client was closed.
+				s.err <- rpc.ErrClientQuit
+				return
+			}
+			if !isResubscribable(err) {
+				s.err <- err
+				return
+			}
+			sub = s.resubscribe()
+			if sub == nil {
+				return
+			}
+		}
+	}
+}
+
+// resubscribe retries the subscription until it succeeds, returning nil if it was
+// cancelled in the meantime.
+func (s *resubscription) resubscribe() *rpc.ClientSubscription {
+	backoff := resubscribeMinBackoff
+	for {
+		select {
+		case <-s.unsub:
+			return nil
+		case <-s.ctx.Done():
+			s.err <- s.ctx.Err()
+			return nil
+		case <-time.After(backoff):
+		}
+		sub, err := s.subscribe(s.ctx)
+		if err == nil {
+			return sub
+		}
+		if backoff *= 2; backoff > resubscribeMaxBackoff {
+			backoff = resubscribeMaxBackoff
+		}
+	}
+}
+
+// isResubscribable returns true if a subscription which failed with err should be
+// re-established, as opposed to ending with the error.
+func isResubscribable(err error) bool {
+	switch err.(type) {
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		// Notifications the channel can't hold won't decode any better next time.
+		return false
+	}
+	return err != rpc.ErrSubscriptionQueueOverflow
+}
@@ -0,0 +1,231 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/crypto"
+)
+
+// TypedData is EIP-712 typed structured data, as signed by eth_signTypedData_v4.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// TypedDataField is a member of an EIP-712 struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// eip712DomainFields are the fields of the EIP712Domain type, in order, used when Types omits it.
+var eip712DomainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// ParseTypedData parses JSON typed data, keeping numbers at full precision.
+func ParseTypedData(b []byte) (*TypedData, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var td TypedData
+	if err := d.Decode(&td); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %v", err)
+	}
+	return &td, nil
+}
+
+// Hash returns the EIP-712 hash to be signed: keccak256("\x19\x01" || domainSeparator || hashStruct(message)).
+func (td *TypedData) Hash() (common.Hash, error) {
+	domain, err := td.DomainSeparator()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	message, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domain[:], message[:]), nil
+}
+
+// DomainSeparator returns the hash of the EIP712Domain struct. If Types doesn't define
+// EIP712Domain, it is made up of the standard fields present in Domain.
+func (td *TypedData) DomainSeparator() (common.Hash, error) {
+	types := td.Types
+	if _, ok := types["EIP712Domain"]; !ok {
+		types = make(map[string][]TypedDataField, len(td.Types)+1)
+		for name, fields := range td.Types {
+			types[name] = fields
+		}
+		var fields []TypedDataField
+		for _, f := range eip712DomainFields {
+			if _, ok := td.Domain[f.Name]; ok {
+				fields = append(fields, f)
+			}
+		}
+		types["EIP712Domain"] = fields
+	}
+	return (&TypedData{Types: types}).HashStruct("EIP712Domain", td.Domain)
+}
+
+// HashStruct returns the EIP-712 hashStruct of data as primaryType.
+func (td *TypedData) HashStruct(primaryType string, data map[string]interface{}) (common.Hash, error) {
+	fields, ok := td.Types[primaryType]
+	if !ok {
+		return common.Hash{}, fmt.Errorf("unknown type %q", primaryType)
+	}
+	typeHash, err := td.TypeHash(primaryType)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	enc := typeHash.Bytes()
+	for _, f := range fields {
+		v, err := td.encodeValue(f.Type, data[f.Name])
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("%s.%s: %v", primaryType, f.Name, err)
+		}
+		enc = append(enc, v...)
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// TypeHash returns the hash of the encoded type, such as "Mail(Person from,Person to,string contents)Person(string name,address wallet)".
+func (td *TypedData) TypeHash(primaryType string) (common.Hash, error) {
+	s, err := td.EncodeType(primaryType)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash([]byte(s)), nil
+}
+
+// EncodeType returns the EIP-712 encoding of primaryType, followed by the struct types it references in name order.
+func (td *TypedData) EncodeType(primaryType string) (string, error) {
+	deps := map[string]bool{}
+	if err := td.dependencies(primaryType, deps); err != nil {
+		return "", err
+	}
+	delete(deps, primaryType)
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range append([]string{primaryType}, names...) {
+		sb.WriteString(name)
+		sb.WriteString("(")
+		for i, f := range td.Types[name] {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(f.Type)
+			sb.WriteString(" ")
+			sb.WriteString(f.Name)
+		}
+		sb.WriteString(")")
+	}
+	return sb.String(), nil
+}
+
+// dependencies adds typ and the struct types it references, directly or indirectly, to deps.
+func (td *TypedData) dependencies(typ string, deps map[string]bool) error {
+	typ = baseType(typ)
+	if deps[typ] {
+		return nil
+	}
+	fields, ok := td.Types[typ]
+	if !ok {
+		return fmt.Errorf("unknown type %q", typ)
+	}
+	deps[typ] = true
+	for _, f := range fields {
+		if _, ok := td.Types[baseType(f.Type)]; ok {
+			if err := td.dependencies(f.Type, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// baseType strips any array suffixes from typ.
+func baseType(typ string) string {
+	if i := strings.Index(typ, "["); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// encodeValue returns the 32 byte EIP-712 encoding of v as typ.
+func (td *TypedData) encodeValue(typ string, v interface{}) ([]byte, error) {
+	if strings.HasSuffix(typ, "]") {
+		elemType := typ[:strings.LastIndex(typ, "[")]
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("expected an array for %s but got %T", typ, v)
+		}
+		var enc []byte
+		for i := 0; i < rv.Len(); i++ {
+			e, err := td.encodeValue(elemType, rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			enc = append(enc, e...)
+		}
+		return crypto.Keccak256(enc), nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object for %s but got %T", typ, v)
+		}
+		h, err := td.HashStruct(typ, m)
+		return h[:], err
+	}
+	switch typ {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string but got %T", v)
+		}
+		return crypto.Keccak256([]byte(s)), nil
+	case "bytes":
+		switch b := v.(type) {
+		case []byte:
+			return crypto.Keccak256(b), nil
+		case string:
+			decoded, err := hexutil.Decode(b)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bytes %q: %v", b, err)
+			}
+			return crypto.Keccak256(decoded), nil
+		}
+		return nil, fmt.Errorf("expected bytes but got %T", v)
+	}
+	abiType, err := abi.NewType(typ, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := v.(float64); ok {
+		v = json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+	}
+	arg, err := ConvertArgument(abiType, v)
+	if err != nil {
+		return nil, err
+	}
+	return abi.Arguments{{Type: abiType}}.Pack(arg)
+}
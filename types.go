@@ -18,6 +18,113 @@ type CallMsg struct {
 	Data     []byte          // input data, usually an ABI-encoded contract method invocation
 }
 
+// BlockNumberOrHash identifies a block by number or hash. The zero value refers to the latest block.
+type BlockNumberOrHash struct {
+	Number *big.Int
+	Hash   *common.Hash
+}
+
+// BlockNumber returns a BlockNumberOrHash for block number n (nil for latest).
+func BlockNumber(n *big.Int) BlockNumberOrHash {
+	return BlockNumberOrHash{Number: n}
+}
+
+// BlockHash returns a BlockNumberOrHash for the block with hash h.
+func BlockHash(h common.Hash) BlockNumberOrHash {
+	return BlockNumberOrHash{Hash: &h}
+}
+
+// StateOverride is a set of per-address account overrides applied while executing a call.
+type StateOverride map[common.Address]OverrideAccount
+
+// OverrideAccount replaces fields of an account for the duration of a call.
+// Nil fields are left unchanged.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[common.Hash]common.Hash // replaces the entire storage
+	StateDiff map[common.Hash]common.Hash // replaces only the given slots
+}
+
+func (o OverrideAccount) MarshalJSON() ([]byte, error) {
+	var r rpcOverrideAccount
+	r.copyFrom(&o)
+	return json.Marshal(&r)
+}
+
+func (o *OverrideAccount) UnmarshalJSON(data []byte) error {
+	var r rpcOverrideAccount
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	r.copyTo(o)
+	return nil
+}
+
+// TxOpts holds optional transaction parameters. Unset fields are filled in from the network.
+//
+// Setting GasPrice sends a legacy transaction. Otherwise, an EIP-1559 dynamic fee transaction
+// is sent if the chain supports it (i.e. the latest block has a base fee), or a legacy
+// transaction at the suggested gas price if it does not.
+type TxOpts struct {
+	GasPrice             *big.Int // legacy gas price, wei
+	MaxFeePerGas         *big.Int // wei; defaults to twice the base fee plus the priority fee
+	MaxPriorityFeePerGas *big.Int // wei; defaults to the suggested priority fee
+	GasLimit             uint64   // if 0, it is estimated (see EstimateGasLimit)
+	Nonce                *uint64  // defaults to the sender's pending nonce, or the next nonce from Nonces
+	// Nonces hands out the nonce when Nonce is unset, and a send which conflicts with another
+	// transaction's nonce is retried with a fresh one. It must be for the sender's address.
+	Nonces *NonceManager
+}
+
+// AccessList is an EIP-2930 access list.
+type AccessList []AccessTuple
+
+// AccessTuple is the element type of an access list.
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// FeeHistory is the fee market history returned by eth_feeHistory.
+type FeeHistory struct {
+	OldestBlock  *big.Int
+	Reward       [][]*big.Int // requested percentiles of priority fees, per block
+	BaseFee      []*big.Int   // per block, plus the next block after the newest
+	GasUsedRatio []float64
+}
+
+func (f *FeeHistory) UnmarshalJSON(data []byte) error {
+	var r rpcFeeHistory
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	r.copyTo(f)
+	return nil
+}
+
+// FilterQuery contains options for log filtering.
+type FilterQuery struct {
+	BlockHash *common.Hash     // used by eth_getLogs, return logs only from block with this hash
+	FromBlock *big.Int         // beginning of the queried range, nil means latest block
+	ToBlock   *big.Int         // end of the range, nil means latest block
+	Addresses []common.Address // restricts matches to events created by specific contracts
+
+	// The Topic list restricts matches to particular event topics. Each event has a list
+	// of topics. Topics matches a prefix of that list. An empty element slice matches any
+	// topic. Non-empty elements represent an alternative that matches any of the
+	// contained topics.
+	//
+	// Examples:
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position AND B in second position
+	// {{A}, {B}}         matches topic A in first position AND B in second position
+	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
+	Topics [][]common.Hash
+}
+
 type Snapshot struct {
 	Number  uint64                      `json:"number"`
 	Hash    common.Hash                 `json:"hash"`
@@ -56,10 +163,13 @@ type Receipt struct {
 	ContractAddress   common.Address
 	GasUsed           uint64
 	ParsedLogs        []Event
+	RevertReason      string // decoded revert reason of a failed transaction, if looked up (see GetRevertReason)
 	BlockHash         common.Hash
 	BlockNumber       uint64
 	From              common.Address
 	To                *common.Address
+	Type              uint64   // EIP-2718 transaction type, 0 for legacy
+	EffectiveGasPrice *big.Int // wei; the price actually paid per gas, if reported
 }
 
 func (r *Receipt) UnmarshalJSON(data []byte) error {
@@ -95,15 +205,40 @@ type Block struct {
 	GasUsed         uint64
 	Timestamp       time.Time
 	ExtraData       []byte
-	MixHash         common.Hash
-	Nonce           types.BlockNonce
+	MixHash         common.Hash      // zero if omitted by the chain
+	Nonce           types.BlockNonce // zero if omitted by the chain
 	Hash            common.Hash
+	BaseFeePerGas   *big.Int // wei; nil before EIP-1559
 
 	// Only one of TxHashes or TxDetails will be populated.
 	TxHashes  []common.Hash
 	TxDetails []*Transaction
 
 	Uncles []common.Hash
+
+	Withdrawals []Withdrawal // nil before EIP-4895
+}
+
+// Withdrawal is a validator withdrawal from the consensus layer (EIP-4895).
+type Withdrawal struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        common.Address
+	Amount         uint64 // gwei
+}
+
+func (w *Withdrawal) UnmarshalJSON(data []byte) error {
+	var r rpcWithdrawal
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	return r.copyTo(w)
+}
+
+func (w Withdrawal) MarshalJSON() ([]byte, error) {
+	var r rpcWithdrawal
+	r.copyFrom(&w)
+	return json.Marshal(&r)
 }
 
 func (b *Block) UnmarshalJSON(data []byte) error {
@@ -139,8 +274,9 @@ func (b *Block) TxCount() int {
 }
 
 type Transaction struct {
+	Type     uint64 // EIP-2718 transaction type, 0 for legacy
 	Nonce    uint64
-	GasPrice *big.Int // wei
+	GasPrice *big.Int // wei; for dynamic fee transactions, the effective or max price as reported by the node
 	GasLimit uint64
 	To       *common.Address
 	Value    *big.Int // wei
@@ -149,15 +285,30 @@ type Transaction struct {
 	V        *big.Int
 	R        *big.Int
 	S        *big.Int
+	YParity  *uint64 // typed transactions only; same as V
 	Hash     common.Hash
 
+	ChainID              *big.Int   // nil for legacy transactions without replay protection
+	MaxFeePerGas         *big.Int   // wei; dynamic fee transactions only
+	MaxPriorityFeePerGas *big.Int   // wei; dynamic fee transactions only
+	AccessList           AccessList // typed transactions only
+
 	BlockNumber      *big.Int
 	BlockHash        common.Hash
 	TransactionIndex uint64
+
+	DecodedInput *CallData // Input decoded against an ABI, if requested (see DecodeCallData)
 }
 type Event struct {
 	Name   string                 `json:"name"`
 	Fields map[string]interface{} `json:"fields"`
+	Hashed []string               `json:"hashed,omitempty"` // indexed fields of dynamic types, whose values are hashes
+
+	Address     common.Address `json:"address"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     uint           `json:"transactionIndex"`
+	LogIndex    uint           `json:"logIndex"`
 }
 
 func (t *Transaction) UnmarshalJSON(data []byte) error {
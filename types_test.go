@@ -0,0 +1,129 @@
+package web3
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/gochain/gochain/v4/common"
+)
+
+// A post-merge Ethereum block header, with the mixHash and nonce removed as some chains do.
+const testBlockJSON = `{
+	"baseFeePerGas": "0x3b9aca00",
+	"difficulty": "0x0",
+	"extraData": "0x",
+	"gasLimit": "0x1c9c380",
+	"gasUsed": "0x5208",
+	"hash": "0x0100000000000000000000000000000000000000000000000000000000000000",
+	"logsBloom": "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+	"miner": "0x0000000000000000000000000000000000000001",
+	"number": "0x10",
+	"parentHash": "0x0200000000000000000000000000000000000000000000000000000000000000",
+	"receiptsRoot": "0x0300000000000000000000000000000000000000000000000000000000000000",
+	"sha3Uncles": "0x0400000000000000000000000000000000000000000000000000000000000000",
+	"stateRoot": "0x0500000000000000000000000000000000000000000000000000000000000000",
+	"timestamp": "0x6553f100",
+	"transactionsRoot": "0x0600000000000000000000000000000000000000000000000000000000000000",
+	"uncles": [],
+	"withdrawals": [
+		{"index": "0x1", "validatorIndex": "0x2", "address": "0x0000000000000000000000000000000000000003", "amount": "0x4"}
+	],
+	"transactions": [{
+		"type": "0x2",
+		"chainId": "0x1",
+		"nonce": "0x7",
+		"gas": "0x5208",
+		"maxFeePerGas": "0x77359400",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"to": "0x0000000000000000000000000000000000000004",
+		"value": "0x1",
+		"input": "0x",
+		"accessList": [{"address": "0x0000000000000000000000000000000000000005", "storageKeys": ["0x0000000000000000000000000000000000000000000000000000000000000001"]}],
+		"yParity": "0x1",
+		"r": "0x1",
+		"s": "0x2",
+		"from": "0x0000000000000000000000000000000000000006",
+		"hash": "0x0700000000000000000000000000000000000000000000000000000000000000"
+	}]
+}`
+
+func TestBlock_UnmarshalJSON(t *testing.T) {
+	var b Block
+	if err := json.Unmarshal([]byte(testBlockJSON), &b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.BaseFeePerGas == nil || b.BaseFeePerGas.Cmp(Gwei(1)) != 0 {
+		t.Errorf("expected base fee %s but got %s", Gwei(1), b.BaseFeePerGas)
+	}
+	expW := []Withdrawal{{Index: 1, ValidatorIndex: 2, Address: common.Address{19: 3}, Amount: 4}}
+	if !reflect.DeepEqual(b.Withdrawals, expW) {
+		t.Errorf("expected withdrawals %v but got %v", expW, b.Withdrawals)
+	}
+	if len(b.TxDetails) != 1 {
+		t.Fatalf("expected 1 transaction but got %d", len(b.TxDetails))
+	}
+	tx := b.TxDetails[0]
+	if tx.Type != DynamicFeeTxType {
+		t.Errorf("expected type %d but got %d", DynamicFeeTxType, tx.Type)
+	}
+	if tx.ChainID.Int64() != 1 || tx.MaxFeePerGas.Cmp(Gwei(2)) != 0 || tx.MaxPriorityFeePerGas.Cmp(Gwei(1)) != 0 {
+		t.Errorf("unexpected chain id or fees: %d %s %s", tx.ChainID, tx.MaxFeePerGas, tx.MaxPriorityFeePerGas)
+	}
+	if tx.GasPrice != nil {
+		t.Errorf("expected no gas price but got %s", tx.GasPrice)
+	}
+	if tx.YParity == nil || *tx.YParity != 1 || tx.V.Int64() != 1 {
+		t.Errorf("expected y parity and v of 1 but got %v and %s", tx.YParity, tx.V)
+	}
+	expAL := AccessList{{Address: common.Address{19: 5}, StorageKeys: []common.Hash{{31: 1}}}}
+	if !reflect.DeepEqual(tx.AccessList, expAL) {
+		t.Errorf("expected access list %v but got %v", expAL, tx.AccessList)
+	}
+
+	// Round trip.
+	data, err := json.Marshal(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b2 Block
+	if err := json.Unmarshal(data, &b2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data2, err := json.Marshal(&b2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(data2) {
+		t.Errorf("round trip mismatch:\nexpected %s\nbut got  %s", data, data2)
+	}
+}
+
+func TestReceipt_UnmarshalJSON(t *testing.T) {
+	const data = `{
+		"type": "0x2",
+		"status": "0x1",
+		"cumulativeGasUsed": "0x5208",
+		"effectiveGasPrice": "0x4a817c800",
+		"logs": [],
+		"transactionHash": "0x0100000000000000000000000000000000000000000000000000000000000000",
+		"transactionIndex": "0x0",
+		"contractAddress": null,
+		"gasUsed": "0x5208",
+		"blockHash": "0x0200000000000000000000000000000000000000000000000000000000000000",
+		"blockNumber": "0x10",
+		"from": "0x0000000000000000000000000000000000000001",
+		"to": "0x0000000000000000000000000000000000000002"
+	}`
+	var r Receipt
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Type != DynamicFeeTxType {
+		t.Errorf("expected type %d but got %d", DynamicFeeTxType, r.Type)
+	}
+	if exp := big.NewInt(20e9); r.EffectiveGasPrice == nil || r.EffectiveGasPrice.Cmp(exp) != 0 {
+		t.Errorf("expected effective gas price %s but got %s", exp, r.EffectiveGasPrice)
+	}
+}
@@ -3,7 +3,6 @@ package web3
 import (
 	"bytes"
 	"context"
-	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,12 +23,18 @@ import (
 	"github.com/gochain/gochain/v4/common/hexutil"
 	"github.com/gochain/gochain/v4/core/types"
 	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/gochain/v4/params"
 	"github.com/gochain/gochain/v4/rlp"
+	"github.com/gochain/gochain/v4/rpc"
 	"github.com/shopspring/decimal"
 )
 
 var NotFoundErr = errors.New("not found")
 
+// GasLimitMultiplier is the safety margin applied to gas estimates when a transaction is
+// sent without a gas limit, since state may change between estimation and execution.
+var GasLimitMultiplier = 1.2
+
 var (
 	weiPerGO   = big.NewInt(1e18)
 	weiPerGwei = big.NewInt(1e9)
@@ -98,6 +104,13 @@ func FloatAsInt(amountF *big.Float, decimals int) *big.Int {
 
 // CallConstantFunction executes a contract function call without submitting a transaction.
 func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, address string, functionName string, params ...interface{}) ([]interface{}, error) {
+	return CallConstantFunctionAt(ctx, client, myabi, address, BlockNumberOrHash{}, nil, functionName, params...)
+}
+
+// CallConstantFunctionAt executes a contract function call against the state of the given block,
+// with optional state overrides (nil for none), without submitting a transaction.
+func CallConstantFunctionAt(ctx context.Context, client Client, myabi abi.ABI, address string, block BlockNumberOrHash,
+	overrides StateOverride, functionName string, params ...interface{}) ([]interface{}, error) {
 	if address == "" {
 		return nil, errors.New("no contract address specified")
 	}
@@ -111,7 +124,7 @@ func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, add
 		return nil, fmt.Errorf("failed to pack values: %v", err)
 	}
 	toAddress := common.HexToAddress(address)
-	res, err := client.Call(ctx, CallMsg{Data: input, To: &toAddress})
+	res, err := client.CallAt(ctx, CallMsg{Data: input, To: &toAddress}, block, overrides)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +137,75 @@ func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, add
 	return convertOutputParams(vals), nil
 }
 
+// CallConstantFunctionNamed is like CallConstantFunction, but returns the outputs keyed by name.
+// See NamedOutputs.
+func CallConstantFunctionNamed(ctx context.Context, client Client, myabi abi.ABI, address string, functionName string, params ...interface{}) (map[string]interface{}, error) {
+	return CallConstantFunctionNamedAt(ctx, client, myabi, address, BlockNumberOrHash{}, nil, functionName, params...)
+}
+
+// CallConstantFunctionNamedAt is like CallConstantFunctionAt, but returns the outputs keyed by name.
+// See NamedOutputs.
+func CallConstantFunctionNamedAt(ctx context.Context, client Client, myabi abi.ABI, address string, block BlockNumberOrHash,
+	overrides StateOverride, functionName string, params ...interface{}) (map[string]interface{}, error) {
+	vals, err := CallConstantFunctionAt(ctx, client, myabi, address, block, overrides, functionName, params...)
+	if err != nil {
+		return nil, err
+	}
+	return NamedOutputs(myabi.Methods[functionName].Outputs, vals), nil
+}
+
+// NamedOutputs returns unpacked values keyed by the names of their args. Unnamed values are keyed
+// by position. Tuples are likewise converted to maps keyed by component name, recursively,
+// including within arrays, and byte arrays to hexutil.Bytes.
+func NamedOutputs(args abi.Arguments, vals []interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(vals))
+	for i, v := range vals {
+		name := strconv.Itoa(i)
+		if i < len(args) {
+			if args[i].Name != "" {
+				name = args[i].Name
+			}
+			v = namedValue(args[i].Type, v)
+		}
+		out[name] = v
+	}
+	return out
+}
+
+func namedValue(t abi.Type, v interface{}) interface{} {
+	switch t.T {
+	case abi.TupleTy:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Struct {
+			return v
+		}
+		m := make(map[string]interface{}, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			name := strconv.Itoa(i)
+			if i < len(t.TupleRawNames) && t.TupleRawNames[i] != "" {
+				name = t.TupleRawNames[i]
+			}
+			m[name] = namedValue(*elem, rv.Field(i).Interface())
+		}
+		return m
+	case abi.SliceTy, abi.ArrayTy:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return v
+		}
+		elems := make([]interface{}, rv.Len())
+		for i := range elems {
+			elems[i] = namedValue(*t.Elem, rv.Index(i).Interface())
+		}
+		return elems
+	case abi.BytesTy:
+		if b, ok := v.([]byte); ok {
+			return hexutil.Bytes(b)
+		}
+	}
+	return convertOutputParams([]interface{}{v})[0]
+}
+
 // CallTransactFunction submits a transaction to execute a smart contract function call.
 // @Deprecated use CallFunctionWithArgs, better signature
 func CallTransactFunction(ctx context.Context, client Client, myabi abi.ABI, address, privateKeyHex, functionName string,
@@ -131,9 +213,32 @@ func CallTransactFunction(ctx context.Context, client Client, myabi abi.ABI, add
 	return CallFunctionWithArgs(ctx, client, privateKeyHex, address, amount, gasPrice, gasLimit, myabi, functionName, params...)
 }
 
+// CallTransactFunctionWithSigner is like CallTransactFunction, signing with signer.
+// @Deprecated use CallFunctionWithSigner, better signature
+func CallTransactFunctionWithSigner(ctx context.Context, client Client, myabi abi.ABI, address string, signer Signer, functionName string,
+	amount *big.Int, gasPrice *big.Int, gasLimit uint64, params ...interface{}) (*Transaction, error) {
+	return CallFunctionWithSigner(ctx, client, signer, address, amount, TxOpts{GasPrice: gasPrice, GasLimit: gasLimit}, myabi, functionName, params...)
+}
+
 // CallFunctionWithArgs submits a transaction to execute a smart contract function call.
 func CallFunctionWithArgs(ctx context.Context, client Client, privateKeyHex, address string,
 	amount *big.Int, gasPrice *big.Int, gasLimit uint64, myabi abi.ABI, functionName string, params ...interface{}) (*Transaction, error) {
+	return CallFunctionWithArgsOpts(ctx, client, privateKeyHex, address, amount, TxOpts{GasPrice: gasPrice, GasLimit: gasLimit}, myabi, functionName, params...)
+}
+
+// CallFunctionWithArgsOpts submits a transaction to execute a smart contract function call, with the given options.
+func CallFunctionWithArgsOpts(ctx context.Context, client Client, privateKeyHex, address string,
+	amount *big.Int, opts TxOpts, myabi abi.ABI, functionName string, params ...interface{}) (*Transaction, error) {
+	signer, err := parsePrivateKey(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return CallFunctionWithSigner(ctx, client, signer, address, amount, opts, myabi, functionName, params...)
+}
+
+// CallFunctionWithSigner is like CallFunctionWithArgsOpts, signing with signer.
+func CallFunctionWithSigner(ctx context.Context, client Client, signer Signer, address string,
+	amount *big.Int, opts TxOpts, myabi abi.ABI, functionName string, params ...interface{}) (*Transaction, error) {
 
 	fn := myabi.Methods[functionName]
 	goParams, err := ConvertArguments(fn.Inputs, params)
@@ -144,58 +249,34 @@ func CallFunctionWithArgs(ctx context.Context, client Client, privateKeyHex, add
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack values: %v", err)
 	}
-	return CallFunctionWithData(ctx, client, privateKeyHex, address, amount, gasPrice, gasLimit, data)
+	return CallFunctionDataWithSigner(ctx, client, signer, address, amount, opts, data)
 }
 
-// CallFunctionWithData if you already have the encoded function data, then use this
+// CallFunctionWithData if you already have the encoded function data, then use this.
+// If gasLimit is 0, it is estimated (see EstimateGasLimit).
 func CallFunctionWithData(ctx context.Context, client Client, privateKeyHex, address string,
 	amount *big.Int, gasPrice *big.Int, gasLimit uint64, data []byte) (*Transaction, error) {
-	if address == "" {
-		return nil, errors.New("no contract address specified")
-	}
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
-	if gasPrice == nil || gasPrice.Int64() == 0 {
-		gasPrice, err = client.GetGasPrice(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("cannot get gas price: %v", err)
-		}
-	}
-	chainID, err := client.GetChainID(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't get chain ID: %v", err)
-	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
-	}
-	toAddress := common.HexToAddress(address)
-	// fmt.Println("Price: ", gasPrice)
-	tx := types.NewTransaction(nonce, toAddress, amount, gasLimit, gasPrice, data)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
-	}
-	raw, err := rlp.EncodeToBytes(signedTx)
+	return CallFunctionWithDataOpts(ctx, client, privateKeyHex, address, amount, TxOpts{GasPrice: gasPrice, GasLimit: gasLimit}, data)
+}
+
+// CallFunctionWithDataOpts is like CallFunctionWithData, with the given transaction options.
+func CallFunctionWithDataOpts(ctx context.Context, client Client, privateKeyHex, address string,
+	amount *big.Int, opts TxOpts, data []byte) (*Transaction, error) {
+	signer, err := parsePrivateKey(privateKeyHex)
 	if err != nil {
 		return nil, err
 	}
-	err = client.SendRawTransaction(ctx, raw)
-	if err != nil {
-		return nil, fmt.Errorf("cannot send transaction: %v", err)
+	return CallFunctionDataWithSigner(ctx, client, signer, address, amount, opts, data)
+}
+
+// CallFunctionDataWithSigner is like CallFunctionWithDataOpts, signing with signer.
+func CallFunctionDataWithSigner(ctx context.Context, client Client, signer Signer, address string,
+	amount *big.Int, opts TxOpts, data []byte) (*Transaction, error) {
+	if address == "" {
+		return nil, errors.New("no contract address specified")
 	}
-	return convertTx(signedTx, fromAddress), nil
+	toAddress := common.HexToAddress(address)
+	return sendTx(ctx, client, signer, &toAddress, amount, data, opts)
 }
 
 func isValidUrl(toTest string) bool {
@@ -222,6 +303,16 @@ func downloadFile(url string) ([]byte, error) {
 // DeployBin will deploy a bin file to the network
 func DeployBin(ctx context.Context, client Client, privateKeyHex, binFilename, abiFilename string,
 	gasPrice *big.Int, gasLimit uint64, constructorArgs ...interface{}) (*Transaction, error) {
+	signer, err := parsePrivateKey(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return DeployBinWithSigner(ctx, client, signer, binFilename, abiFilename, TxOpts{GasPrice: gasPrice, GasLimit: gasLimit}, constructorArgs...)
+}
+
+// DeployBinWithSigner is like DeployBin, signing with signer and with the given transaction options.
+func DeployBinWithSigner(ctx context.Context, client Client, signer Signer, binFilename, abiFilename string,
+	opts TxOpts, constructorArgs ...interface{}) (*Transaction, error) {
 	var bin []byte
 	var err error
 	if isValidUrl(binFilename) {
@@ -250,48 +341,33 @@ func DeployBin(ctx context.Context, client Client, privateKeyHex, binFilename, a
 		}
 	}
 
-	return DeployContract(ctx, client, privateKeyHex, string(bin), string(abi), gasPrice, gasLimit, constructorArgs...)
+	return DeployContractWithSigner(ctx, client, signer, string(bin), string(abi), opts, constructorArgs...)
 }
 
 // DeployContract submits a contract creation transaction.
 // abiJSON is only required when including params for the constructor.
+// If gasLimit is 0, it is estimated (see EstimateGasLimit).
 func DeployContract(ctx context.Context, client Client, privateKeyHex string, binHex, abiJSON string, gasPrice *big.Int, gasLimit uint64, constructorArgs ...interface{}) (*Transaction, error) {
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
+	return DeployContractWithOpts(ctx, client, privateKeyHex, binHex, abiJSON, TxOpts{GasPrice: gasPrice, GasLimit: gasLimit}, constructorArgs...)
+}
 
-	if gasPrice == nil || gasPrice.Int64() == 0 {
-		gasPrice, err = client.GetGasPrice(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("cannot get gas price: %v", err)
-		}
-	}
-	chainID, err := client.GetChainID(ctx)
+// DeployContractWithOpts is like DeployContract, with the given transaction options.
+func DeployContractWithOpts(ctx context.Context, client Client, privateKeyHex string, binHex, abiJSON string, opts TxOpts, constructorArgs ...interface{}) (*Transaction, error) {
+	signer, err := parsePrivateKey(privateKeyHex)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't get chain ID: %v", err)
-	}
-
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
+		return nil, err
 	}
+	return DeployContractWithSigner(ctx, client, signer, binHex, abiJSON, opts, constructorArgs...)
+}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
-	}
+// DeployContractWithSigner is like DeployContractWithOpts, signing with signer.
+func DeployContractWithSigner(ctx context.Context, client Client, signer Signer, binHex, abiJSON string, opts TxOpts, constructorArgs ...interface{}) (*Transaction, error) {
 	binData, err := hexutil.Decode(binHex)
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode contract data: %v", err)
 	}
 	if len(constructorArgs) > 0 {
-		abiData, err := abi.JSON(strings.NewReader(abiJSON))
+		abiData, err := readAbi(strings.NewReader(abiJSON))
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse ABI: %v", err)
 		}
@@ -305,67 +381,290 @@ func DeployContract(ctx context.Context, client Client, privateKeyHex string, bi
 		}
 		binData = append(binData, input...)
 	}
-	//TODO try to use web3.Transaction only; can't sign currently
-	tx := types.NewContractCreation(nonce, big.NewInt(0), gasLimit, gasPrice, binData)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
-	}
-	raw, err := rlp.EncodeToBytes(signedTx)
+	return sendTx(ctx, client, signer, nil, nil, binData, opts)
+}
+
+// Send performs a regular native coin transaction (not a contract).
+// If gasLimit is 0, it is estimated (see EstimateGasLimit).
+func Send(ctx context.Context, client Client, privateKeyHex string, address common.Address, amount *big.Int, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+	return SendWithOpts(ctx, client, privateKeyHex, address, amount, TxOpts{GasPrice: gasPrice, GasLimit: gasLimit})
+}
+
+// SendWithOpts is like Send, with the given transaction options.
+func SendWithOpts(ctx context.Context, client Client, privateKeyHex string, address common.Address, amount *big.Int, opts TxOpts) (*Transaction, error) {
+	signer, err := parsePrivateKey(privateKeyHex)
 	if err != nil {
 		return nil, err
 	}
-	err = client.SendRawTransaction(ctx, raw)
-	if err != nil {
-		return nil, fmt.Errorf("cannot send transaction: %v", err)
-	}
+	return SendWithSigner(ctx, client, signer, address, amount, opts)
+}
 
-	return convertTx(signedTx, fromAddress), nil
+// SendWithSigner is like SendWithOpts, signing with signer.
+func SendWithSigner(ctx context.Context, client Client, signer Signer, address common.Address, amount *big.Int, opts TxOpts) (*Transaction, error) {
+	return sendTx(ctx, client, signer, &address, amount, nil, opts)
 }
 
-// Send performs a regular native coin transaction (not a contract)
-func Send(ctx context.Context, client Client, privateKeyHex string, address common.Address, amount *big.Int, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+func parsePrivateKey(privateKeyHex string) (*Account, error) {
+	account, err := ParsePrivateKey(privateKeyHex)
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %v", err)
 	}
-	if gasPrice == nil || gasPrice.Int64() == 0 {
-		gasPrice, err = client.GetGasPrice(ctx)
+	return account, nil
+}
+
+// sendTx signs and sends a transaction. to is nil for contract creation.
+// Unless opts.Nonce is set, the nonce comes from opts.Nonces (or the pending nonce), and the
+// transaction is re-signed with a fresh nonce if the node rejects it because the nonce was used.
+func sendTx(ctx context.Context, client Client, signer Signer, to *common.Address, amount *big.Int, data []byte, opts TxOpts) (*Transaction, error) {
+	if opts.Nonce != nil || opts.Nonces == nil {
+		raw, tx, err := signTx(ctx, client, signer, to, amount, data, opts)
 		if err != nil {
-			return nil, fmt.Errorf("cannot get gas price: %v", err)
+			return nil, err
+		}
+		if err := client.SendRawTransaction(ctx, raw); err != nil {
+			return nil, fmt.Errorf("cannot send transaction: %v", err)
 		}
+		return tx, nil
 	}
+	nonces := opts.Nonces
+	if nonces.Address() != signer.Address() {
+		return nil, fmt.Errorf("nonce manager is for %s, not the sender %s", nonces.Address().Hex(), signer.Address().Hex())
+	}
+	for attempt := 0; ; attempt++ {
+		nonce, err := nonces.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts.Nonce = &nonce
+		raw, tx, err := signTx(ctx, client, signer, to, amount, data, opts)
+		if err != nil {
+			nonces.Release(nonce)
+			return nil, err
+		}
+		err = client.SendRawTransaction(ctx, raw)
+		if err == nil || IsKnownTxError(err) {
+			// A known transaction was already sent, e.g. by an earlier attempt which timed out.
+			return tx, nil
+		}
+		if !IsNonceError(err) {
+			nonces.Release(nonce)
+			return nil, fmt.Errorf("cannot send transaction: %v", err)
+		}
+		if attempt == maxNonceRetries {
+			return nil, fmt.Errorf("cannot send transaction: %v", err)
+		}
+		// Another transaction took the nonce, so catch up with the network and try the next one.
+		if err := nonces.Sync(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// signTx builds a transaction from opts, filling in any unset fields from the network, and signs it.
+// It returns the raw transaction bytes for SendRawTransaction.
+func signTx(ctx context.Context, client Client, signer Signer, to *common.Address, amount *big.Int, data []byte, opts TxOpts) ([]byte, *Transaction, error) {
+	tx, err := BuildTransaction(ctx, client, signer.Address(), to, amount, data, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := signer.SignTx(ctx, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot sign transaction: %v", err)
+	}
+	return raw, tx, nil
+}
+
+// BuildTransaction returns an unsigned transaction from the from address, filling in the chain ID
+// and any of the nonce, gas limit and fees unset in opts from the network. to is nil for contract creation.
+// Use NewTransaction instead to build a transaction offline.
+func BuildTransaction(ctx context.Context, client Client, from common.Address, to *common.Address, amount *big.Int, data []byte, opts TxOpts) (*Transaction, error) {
 	chainID, err := client.GetChainID(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't get chain ID: %v", err)
 	}
+	if opts.Nonce == nil {
+		nonce, err := client.GetPendingTransactionCount(ctx, from)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get nonce: %v", err)
+		}
+		opts.Nonce = &nonce
+	}
+	if amount == nil {
+		amount = new(big.Int)
+	}
+	if opts.GasLimit == 0 {
+		opts.GasLimit, err = EstimateGasLimit(ctx, client, CallMsg{From: &from, To: to, Value: amount, Data: data})
+		if err != nil {
+			return nil, err
+		}
+	}
+	maxFee, priorityFee, err := dynamicFees(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+	if maxFee != nil {
+		opts.MaxFeePerGas, opts.MaxPriorityFeePerGas = maxFee, priorityFee
+	} else {
+		opts.MaxFeePerGas, opts.MaxPriorityFeePerGas = nil, nil
+		if opts.GasPrice == nil || opts.GasPrice.Sign() == 0 {
+			opts.GasPrice, err = client.GetGasPrice(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get gas price: %v", err)
+			}
+		}
+	}
+	return NewTransaction(chainID, to, amount, data, opts)
+}
 
-	if gasLimit == 0 {
-		gasLimit = 21000
+// NewTransaction returns an unsigned transaction built only from its arguments, without a network
+// connection, so it can be signed offline with Signer.SignTx. to is nil for contract creation.
+// opts must set the Nonce and GasLimit, and either the GasPrice of a legacy transaction, or the
+// MaxFeePerGas and MaxPriorityFeePerGas of a dynamic fee transaction.
+func NewTransaction(chainID *big.Int, to *common.Address, amount *big.Int, data []byte, opts TxOpts) (*Transaction, error) {
+	if chainID == nil || chainID.Sign() <= 0 {
+		return nil, errors.New("missing chain ID")
 	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
+	if opts.Nonce == nil {
+		return nil, errors.New("missing nonce")
+	}
+	if opts.GasLimit == 0 {
+		return nil, errors.New("missing gas limit")
+	}
+	if amount == nil {
+		amount = new(big.Int)
+	}
+	tx := &Transaction{
+		ChainID:  chainID,
+		Nonce:    *opts.Nonce,
+		GasLimit: opts.GasLimit,
+		To:       to,
+		Value:    amount,
+		Input:    data,
+	}
+	switch {
+	case opts.GasPrice != nil && opts.GasPrice.Sign() > 0:
+		if opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil {
+			return nil, errors.New("gas price cannot be combined with max fee or priority fee")
+		}
+		tx.GasPrice = opts.GasPrice
+	case opts.MaxFeePerGas != nil:
+		if opts.MaxPriorityFeePerGas == nil {
+			return nil, errors.New("missing max priority fee per gas")
+		}
+		if opts.MaxPriorityFeePerGas.Cmp(opts.MaxFeePerGas) > 0 {
+			return nil, fmt.Errorf("max priority fee per gas (%s) is higher than max fee per gas (%s)", opts.MaxPriorityFeePerGas, opts.MaxFeePerGas)
+		}
+		tx.Type = DynamicFeeTxType
+		tx.MaxFeePerGas, tx.MaxPriorityFeePerGas = opts.MaxFeePerGas, opts.MaxPriorityFeePerGas
+	default:
+		return nil, errors.New("missing gas price, or max fee and priority fee")
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
+	return tx, nil
+}
+
+// DecodeRawTransaction decodes a signed raw transaction, as returned by Signer.SignTx, recovering its sender.
+func DecodeRawTransaction(raw []byte) (*Transaction, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("empty transaction")
+	}
+	if raw[0] == DynamicFeeTxType {
+		var dtx DynamicFeeTx
+		if err := rlp.DecodeBytes(raw[1:], &dtx); err != nil {
+			return nil, fmt.Errorf("invalid dynamic fee transaction: %v", err)
+		}
+		from, err := dtx.Sender()
+		if err != nil {
+			return nil, err
+		}
+		return convertDynamicFeeTx(&dtx, crypto.Keccak256Hash(raw), from), nil
+	}
+	if raw[0] < 0xc0 {
+		return nil, fmt.Errorf("unsupported transaction type %d", raw[0])
+	}
+	var ltx types.Transaction
+	if err := rlp.DecodeBytes(raw, &ltx); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if ltx.Protected() {
+		signer = types.NewEIP155Signer(ltx.ChainId())
+	}
+	from, err := types.Sender(signer, &ltx)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
+		return nil, fmt.Errorf("invalid transaction signature: %v", err)
+	}
+	return convertTx(&ltx, from), nil
+}
+
+// dynamicFees returns the fees for a dynamic fee transaction, filling in those unset in opts
+// from the network. It returns nils if a legacy transaction should be sent instead, either
+// because opts.GasPrice is set or because the chain does not support EIP-1559, in which case
+// opts must not set max fees.
+func dynamicFees(ctx context.Context, client Client, opts TxOpts) (maxFee, priorityFee *big.Int, err error) {
+	if opts.GasPrice != nil && opts.GasPrice.Sign() > 0 {
+		return nil, nil, nil
 	}
-	tx := types.NewTransaction(nonce, address, amount, gasLimit, gasPrice, nil)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	baseFee, err := nextBaseFee(ctx, client)
 	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		return nil, nil, err
 	}
-	err = SendTransaction(ctx, client, signedTx)
+	if baseFee == nil {
+		if opts.MaxFeePerGas != nil || opts.MaxPriorityFeePerGas != nil {
+			return nil, nil, errors.New("the chain does not support EIP-1559 fees, so set a gas price instead of max fees")
+		}
+		return nil, nil, nil
+	}
+	maxFee, priorityFee = opts.MaxFeePerGas, opts.MaxPriorityFeePerGas
+	if priorityFee == nil {
+		priorityFee, err = client.GetMaxPriorityFeePerGas(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot get max priority fee: %v", err)
+		}
+		if maxFee != nil && priorityFee.Cmp(maxFee) > 0 {
+			priorityFee = maxFee
+		}
+	}
+	if maxFee == nil {
+		maxFee = new(big.Int).Mul(baseFee, big.NewInt(2))
+		maxFee.Add(maxFee, priorityFee)
+	}
+	if priorityFee.Cmp(maxFee) > 0 {
+		return nil, nil, fmt.Errorf("max priority fee per gas (%s) is higher than max fee per gas (%s)", priorityFee, maxFee)
+	}
+	return maxFee, priorityFee, nil
+}
+
+// nextBaseFee returns the base fee of the next block, or nil if the chain does not support EIP-1559.
+func nextBaseFee(ctx context.Context, client Client) (*big.Int, error) {
+	h, err := client.GetFeeHistory(ctx, 1, nil, nil)
+	if err != nil {
+		if e, ok := err.(rpc.Error); ok && e.ErrorCode() == -32601 {
+			// Method not found, so the node predates EIP-1559.
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot get fee history: %v", err)
+	}
+	if len(h.BaseFee) == 0 {
+		return nil, nil
+	}
+	baseFee := h.BaseFee[len(h.BaseFee)-1]
+	if baseFee == nil || baseFee.Sign() == 0 {
+		return nil, nil
+	}
+	return baseFee, nil
+}
+
+// EstimateGasLimit estimates the gas required by msg and applies GasLimitMultiplier.
+// Plain transfers, which always cost exactly params.TxGas, are returned as is.
+func EstimateGasLimit(ctx context.Context, client Client, msg CallMsg) (uint64, error) {
+	gas, err := client.EstimateGas(ctx, msg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %v", err)
+		return 0, fmt.Errorf("cannot estimate gas: %v", err)
 	}
-	return convertTx(signedTx, fromAddress), nil
+	if gas == params.TxGas {
+		return gas, nil
+	}
+	return uint64(float64(gas) * GasLimitMultiplier), nil
 }
 
 // SendTransaction sends the Transaction
@@ -377,6 +676,28 @@ func SendTransaction(ctx context.Context, client Client, signedTx *types.Transac
 	return client.SendRawTransaction(ctx, raw)
 }
 
+// convertDynamicFeeTx converts a signed dynamic fee transaction with the given hash.
+func convertDynamicFeeTx(tx *DynamicFeeTx, hash common.Hash, from common.Address) *Transaction {
+	rtx := &Transaction{}
+	rtx.Type = DynamicFeeTxType
+	rtx.ChainID = tx.ChainID
+	rtx.Nonce = tx.Nonce
+	rtx.GasPrice = tx.MaxFeePerGas // as reported by nodes while pending
+	rtx.MaxFeePerGas = tx.MaxFeePerGas
+	rtx.MaxPriorityFeePerGas = tx.MaxPriorityFeePerGas
+	rtx.AccessList = tx.AccessList
+	rtx.GasLimit = tx.Gas
+	rtx.To = tx.To
+	rtx.Value = tx.Value
+	rtx.Input = tx.Data
+	rtx.Hash = hash
+	rtx.From = from
+	rtx.V, rtx.R, rtx.S = tx.V, tx.R, tx.S
+	yParity := tx.V.Uint64()
+	rtx.YParity = &yParity
+	return rtx
+}
+
 func convertTx(tx *types.Transaction, from common.Address) *Transaction {
 	rtx := &Transaction{}
 	rtx.Nonce = tx.Nonce()
@@ -388,6 +709,9 @@ func convertTx(tx *types.Transaction, from common.Address) *Transaction {
 	rtx.Hash = tx.Hash()
 	rtx.From = from
 	rtx.V, rtx.R, rtx.S = tx.RawSignatureValues()
+	if tx.Protected() {
+		rtx.ChainID = tx.ChainId()
+	}
 	return rtx
 }
 
@@ -414,6 +738,7 @@ func ConvertArguments(args abi.Arguments, params []interface{}) ([]interface{},
 }
 
 // ConvertArgument attempts to convert argument to the provided ABI type and size.
+// Arrays and tuples are converted recursively, and may be given as JSON strings.
 // Unrecognized types are passed through unmodified.
 func ConvertArgument(abiType abi.Type, param interface{}) (interface{}, error) {
 	size := abiType.Size
@@ -460,63 +785,9 @@ func ConvertArgument(abiType abi.Type, param interface{}) (interface{}, error) {
 			return common.HexToAddress(s), nil
 		}
 	case abi.SliceTy, abi.ArrayTy:
-		s, ok := param.(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid array: %s", s)
-		}
-		s = strings.TrimPrefix(s, "[")
-		s = strings.TrimSuffix(s, "]")
-		inputArray := strings.Split(s, ",")
-		if s == "" {
-			inputArray = nil
-		}
-		switch abiType.Elem.T {
-
-		case abi.AddressTy:
-			arrayParams := make([]common.Address, len(inputArray))
-			for i, elem := range inputArray {
-				converted, err := ConvertArgument(*abiType.Elem, elem)
-				if err != nil {
-					return nil, err
-				}
-				arrayParams[i] = converted.(common.Address)
-			}
-			return arrayParams, nil
-
-		case abi.StringTy:
-			arrayParams := make([]string, len(inputArray))
-			for i, elem := range inputArray {
-				converted, err := ConvertArgument(*abiType.Elem, elem)
-				if err != nil {
-					return nil, err
-				}
-				arrayParams[i] = converted.(string)
-			}
-			return arrayParams, nil
-
-		case abi.BoolTy:
-			arrayParams := make([]bool, len(inputArray))
-			for i, elem := range inputArray {
-				converted, err := ConvertArgument(*abiType.Elem, elem)
-				if err != nil {
-					return nil, err
-				}
-				arrayParams[i] = converted.(bool)
-			}
-			return arrayParams, nil
-
-		default:
-			arrayParams := make([]int, len(inputArray))
-			for i, elem := range inputArray {
-				converted, err := ConvertArgument(*abiType.Elem, elem)
-				if err != nil {
-					return nil, err
-				}
-				arrayParams[i] = converted.(int)
-			}
-			return arrayParams, nil
-		}
-
+		return convertArray(abiType, param)
+	case abi.TupleTy:
+		return convertTuple(abiType, param)
 	case abi.BytesTy:
 		if s, ok := param.(string); ok {
 			val, err := hexutil.Decode(s)
@@ -551,7 +822,6 @@ func ConvertArgument(abiType abi.Type, param interface{}) (interface{}, error) {
 			}
 		default:
 			if s, ok := param.(string); ok {
-				fmt.Println(s)
 				val, err := hexutil.Decode(s)
 				if err != nil {
 					return nil, fmt.Errorf("failed to parse hash %q: %v", s, err)
@@ -571,6 +841,144 @@ func ConvertArgument(abiType abi.Type, param interface{}) (interface{}, error) {
 	return param, nil
 }
 
+// convertArray converts param to a slice or array of the abiType element type. Params may be
+// slices or arrays of any convertible elements, or strings holding a JSON array. For arrays of
+// non-tuple types the brackets and quotes can be dropped, as in "[0xabc,0xdef]" or "1,2,3".
+func convertArray(abiType abi.Type, param interface{}) (interface{}, error) {
+	typ := abiType.GetType()
+	if reflect.TypeOf(param) == typ {
+		return param, nil
+	}
+	if s, ok := param.(string); ok {
+		elems, err := parseJSONArray(s)
+		if err != nil {
+			if abiType.Elem.T == abi.TupleTy || abiType.Elem.T == abi.SliceTy || abiType.Elem.T == abi.ArrayTy {
+				return nil, fmt.Errorf("invalid %s: %v", abiType, err)
+			}
+			elems = splitArray(s)
+		}
+		param = elems
+	}
+	v := reflect.ValueOf(param)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("invalid %s: expected an array but got %T", abiType, param)
+	}
+	var out reflect.Value
+	if abiType.T == abi.ArrayTy {
+		if v.Len() != abiType.Size {
+			return nil, fmt.Errorf("invalid %s: expected %d elements but got %d", abiType, abiType.Size, v.Len())
+		}
+		out = reflect.New(typ).Elem()
+	} else {
+		out = reflect.MakeSlice(typ, v.Len(), v.Len())
+	}
+	for i := 0; i < v.Len(); i++ {
+		elem, err := ConvertArgument(*abiType.Elem, v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s element %d: %v", abiType, i, err)
+		}
+		if err := setValue(out.Index(i), elem); err != nil {
+			return nil, fmt.Errorf("invalid %s element %d: %v", abiType, i, err)
+		}
+	}
+	return out.Interface(), nil
+}
+
+// convertTuple converts param to the struct type of a tuple. Params may be maps keyed by
+// component name, slices or arrays of the components in order, or strings holding either as JSON.
+func convertTuple(abiType abi.Type, param interface{}) (interface{}, error) {
+	if reflect.TypeOf(param) == abiType.TupleType {
+		return param, nil
+	}
+	if s, ok := param.(string); ok {
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		if err := d.Decode(&param); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", abiType, s, err)
+		}
+	}
+	out := reflect.New(abiType.TupleType).Elem()
+	set := func(i int, val interface{}) error {
+		elem, err := ConvertArgument(*abiType.TupleElems[i], val)
+		if err != nil {
+			return fmt.Errorf("invalid %s component %q: %v", abiType, abiType.TupleRawNames[i], err)
+		}
+		if err := setValue(out.Field(i), elem); err != nil {
+			return fmt.Errorf("invalid %s component %q: %v", abiType, abiType.TupleRawNames[i], err)
+		}
+		return nil
+	}
+	if m, ok := param.(map[string]interface{}); ok {
+		if len(m) != len(abiType.TupleElems) {
+			return nil, fmt.Errorf("invalid %s: expected %d components but got %d", abiType, len(abiType.TupleElems), len(m))
+		}
+		for i, name := range abiType.TupleRawNames {
+			val, ok := m[name]
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: missing component %q", abiType, name)
+			}
+			if err := set(i, val); err != nil {
+				return nil, err
+			}
+		}
+		return out.Interface(), nil
+	}
+	v := reflect.ValueOf(param)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("invalid %s: expected an object or array but got %T", abiType, param)
+	}
+	if v.Len() != len(abiType.TupleElems) {
+		return nil, fmt.Errorf("invalid %s: expected %d components but got %d", abiType, len(abiType.TupleElems), v.Len())
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := set(i, v.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return out.Interface(), nil
+}
+
+// parseJSONArray parses s as a JSON array, keeping numbers as json.Number.
+func parseJSONArray(s string) ([]interface{}, error) {
+	var elems []interface{}
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	if err := d.Decode(&elems); err != nil {
+		return nil, err
+	}
+	return elems, nil
+}
+
+// splitArray splits the legacy "[a,b,c]" array format.
+func splitArray(s string) []interface{} {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	if strings.TrimSpace(s) == "" {
+		return []interface{}{}
+	}
+	parts := strings.Split(s, ",")
+	elems := make([]interface{}, len(parts))
+	for i, p := range parts {
+		elems[i] = strings.TrimSpace(p)
+	}
+	return elems
+}
+
+// setValue sets v to val, converting between compatible types such as common.Hash and [32]byte.
+func setValue(v reflect.Value, val interface{}) error {
+	rv := reflect.ValueOf(val)
+	switch {
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+	case rv.Type().ConvertibleTo(v.Type()) && rv.Kind() == v.Kind():
+		v.Set(rv.Convert(v.Type()))
+	default:
+		return fmt.Errorf("cannot use %T as %s", val, v.Type())
+	}
+	return nil
+}
+
 func convertOutputParams(params []interface{}) []interface{} {
 	for i := range params {
 		p := params[i]
@@ -595,62 +1003,13 @@ func convertOutputParams(params []interface{}) []interface{} {
 	return params
 }
 
-//TODO Deprecated: prefer built-in UnpackValues() func and convertOutputParams.
-func convertOutputParameter(t abi.Argument) (interface{}, error) {
-	switch t.Type.T {
-	case abi.BoolTy:
-		return new(bool), nil
-	case abi.UintTy:
-		switch size := t.Type.Size; {
-		case size > 64:
-			i := new(big.Int)
-			return &i, nil
-		case size > 32:
-			return new(uint64), nil
-		case size > 16:
-			return new(uint32), nil
-		case size > 8:
-			return new(uint16), nil
-		default:
-			return new(uint8), nil
-		}
-	case abi.IntTy:
-		switch size := t.Type.Size; {
-		case size > 64:
-			i := new(big.Int)
-			return &i, nil
-		case size > 32:
-			return new(int64), nil
-		case size > 16:
-			return new(int32), nil
-		case size > 8:
-			return new(int16), nil
-		default:
-			return new(int8), nil
-		}
-	case abi.StringTy:
-		return new(string), nil
-	case abi.AddressTy:
-		return new(common.Address), nil
-	case abi.BytesTy:
-		return new(hexutil.Bytes), nil
-	case abi.FixedBytesTy:
-		switch size := t.Type.Size; {
-		case size == 32:
-			return new(common.Hash), nil
-		default:
-			return nil, fmt.Errorf("unsupported output byte array size %v", size)
-		}
-	default:
-		return nil, fmt.Errorf("unsupported output type %v", t.Type.T)
-	}
-}
-
 // ConvertInt converts a big.Int in to the provided type.
 func ConvertInt(signed bool, size int, i *big.Int) (interface{}, error) {
+	// Only sizes with a matching Go integer type are converted, as in abi.Type.GetType.
+	nonNative := size != 8 && size != 16 && size != 32 && size != 64
 	if signed {
 		switch {
-		case size > 64:
+		case nonNative:
 			return i, nil
 		case size > 32:
 			if !i.IsInt64() {
@@ -675,7 +1034,7 @@ func ConvertInt(signed bool, size int, i *big.Int) (interface{}, error) {
 		}
 	} else {
 		switch {
-		case size > 64:
+		case nonNative:
 			if i.Sign() == -1 {
 				return nil, fmt.Errorf("negative value in unsigned field: %s", i)
 			}
@@ -730,56 +1089,184 @@ func FindEventById(abi abi.ABI, id common.Hash) *abi.Event {
 	}
 	return nil
 }
-func getInputs(args abi.Arguments, indexed bool) []abi.Argument {
-	var out []abi.Argument
-	for _, arg := range args {
-		if arg.Indexed == indexed {
-			out = append(out, arg)
-		}
+
+// ErrUnknownEvent is returned by ParseLog for logs which don't match any event in the ABI.
+var ErrUnknownEvent = errors.New("unknown event")
+
+// LogError is the failure to parse a log, as reported by ParseLogs.
+type LogError struct {
+	Log *types.Log
+	Err error
+}
+
+func (e *LogError) Error() string {
+	return fmt.Sprintf("log %d of transaction %s: %v", e.Log.Index, e.Log.TxHash.Hex(), e.Err)
+}
+
+// LogErrors is returned by ParseLogs for the logs which failed to parse.
+type LogErrors []*LogError
+
+func (e LogErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
 	}
-	return out
+	return strings.Join(msgs, "; ")
 }
 
-// func ParseReceipt(myabi abi.ABI, receipt *Receipt) (map[string]map[string]interface{}, error) {
+// ParseLogs parses logs emitted by the contract with myabi. Logs which don't match any event in
+// the ABI, such as those from other contracts, are skipped. See ParseLog. Logs which match an
+// event but fail to parse are skipped too, and reported by a LogErrors error, which is returned
+// along with the events of the other logs.
 func ParseLogs(myabi abi.ABI, logs []*types.Log) ([]Event, error) {
-	var output []Event
-	// output := make(map[string]map[string]interface{})
+	var events []Event
+	var errs LogErrors
 	for _, log := range logs {
-		var out []interface{}
-		//event id is always in the first topic
-		event := FindEventById(myabi, log.Topics[0])
-		fields := make(map[string]interface{})
-		//TODO use event.Inputs.UnpackIntoMap instead when available
-		nonIndexed := getInputs(event.Inputs, false)
-		for _, t := range nonIndexed {
-			x, err := convertOutputParameter(t)
-			if err != nil {
-				return nil, err
-			}
-			out = append(out, x)
+		if log == nil {
+			continue
 		}
-		if len(nonIndexed) > 1 {
-			out, err := myabi.Unpack(event.Name, log.Data)
-			if err != nil {
-				return nil, err
-			}
-			for i, o := range out {
-				fields[nonIndexed[i].Name] = reflect.ValueOf(o).Elem().Interface()
-			}
-		} else if len(out) > 0 {
-			o, err := myabi.Unpack(event.Name, log.Data)
-			if err != nil {
-				return nil, err
+		e, err := ParseLog(myabi, log)
+		if err == ErrUnknownEvent {
+			continue
+		} else if err != nil {
+			errs = append(errs, &LogError{Log: log, Err: err})
+			continue
+		}
+		events = append(events, *e)
+	}
+	if len(errs) > 0 {
+		return events, errs
+	}
+	return events, nil
+}
+
+// ParseLogsByAddress is like ParseLogs, but parses each log with the ABI of the contract which
+// emitted it, so that logs from several contracts, as in a single receipt, can be parsed together.
+// Logs from contracts missing from abis are skipped.
+func ParseLogsByAddress(abis map[common.Address]abi.ABI, logs []*types.Log) ([]Event, error) {
+	var events []Event
+	var errs LogErrors
+	for _, log := range logs {
+		if log == nil {
+			continue
+		}
+		myabi, ok := abis[log.Address]
+		if !ok {
+			continue
+		}
+		e, err := ParseLog(myabi, log)
+		if err == ErrUnknownEvent {
+			continue
+		} else if err != nil {
+			errs = append(errs, &LogError{Log: log, Err: err})
+			continue
+		}
+		events = append(events, *e)
+	}
+	if len(errs) > 0 {
+		return events, errs
+	}
+	return events, nil
+}
+
+// ParseLog parses a log emitted by the contract with myabi. The event is identified by the first
+// topic, or for anonymous events, which have no signature topic, by being the first which the log
+// is a valid encoding of. Indexed values are decoded from their topics, except for those of dynamic
+// types (strings, bytes, arrays and tuples), which only have their hash stored: these are returned
+// as the topic hash, and their names listed in Event.Hashed. ErrUnknownEvent is returned if no event
+// in the ABI matches, including when the first topic matches but the number of topics doesn't, as
+// for ERC-721 Transfer logs parsed with an ERC-20 ABI.
+func ParseLog(myabi abi.ABI, log *types.Log) (*Event, error) {
+	if log == nil {
+		return nil, errors.New("nil log")
+	}
+	if len(log.Topics) > 0 {
+		if event := FindEventById(myabi, log.Topics[0]); event != nil && !event.Anonymous {
+			if indexedCount(event.Inputs)+1 == len(log.Topics) {
+				return parseEvent(event.Name, event.Inputs, log.Topics[1:], log, false)
 			}
-			fields[nonIndexed[0].Name] = o
 		}
+	}
+	names := make([]string, 0, len(myabi.Events))
+	for name, event := range myabi.Events {
+		if event.Anonymous {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event := myabi.Events[name]
+		if indexedCount(event.Inputs) != len(log.Topics) {
+			continue
+		}
+		if e, err := parseEvent(event.Name, event.Inputs, log.Topics, log, true); err == nil {
+			return e, nil
+		}
+	}
+	return nil, ErrUnknownEvent
+}
 
-		for i, input := range getInputs(event.Inputs, true) {
-			fields[input.Name] = log.Topics[i+1].String()
+// indexedCount returns the number of indexed inputs.
+func indexedCount(inputs abi.Arguments) int {
+	var n int
+	for _, input := range inputs {
+		if input.Indexed {
+			n++
+		}
+	}
+	return n
+}
+
+// parseEvent decodes log as the event with inputs, given the topics holding its indexed values.
+// If exact is set, the data must be exactly the encoding of the non-indexed values.
+func parseEvent(name string, inputs abi.Arguments, topics []common.Hash, log *types.Log, exact bool) (*Event, error) {
+	nonIndexed := inputs.NonIndexed()
+	var vals []interface{}
+	var err error
+	if exact {
+		vals, err = unpackExact(nonIndexed, log.Data)
+	} else {
+		vals, err = nonIndexed.UnpackValues(log.Data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s data: %v", name, err)
+	}
+	e := &Event{
+		Name:        name,
+		Fields:      make(map[string]interface{}, len(inputs)),
+		Address:     log.Address,
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+		TxIndex:     log.TxIndex,
+		LogIndex:    log.Index,
+	}
+	for i, input := range inputs {
+		key := input.Name
+		if key == "" {
+			key = strconv.Itoa(i)
+		}
+		if !input.Indexed {
+			e.Fields[key] = namedValue(input.Type, vals[0])
+			vals = vals[1:]
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("missing topic for indexed %s field %q", name, key)
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if !isTopicValueType(input.Type) {
+			e.Fields[key] = topic
+			e.Hashed = append(e.Hashed, key)
+			continue
+		}
+		v, err := abi.Arguments{{Type: input.Type}}.UnpackValues(topic[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack indexed %s field %q: %v", name, key, err)
 		}
-		output = append(output, Event{Name: event.Name, Fields: fields})
+		e.Fields[key] = namedValue(input.Type, v[0])
 	}
-	return output, nil
+	return e, nil
 }
 
 // ParseAmount parses a string (human readable amount with units ie 1go, 1nanogo...) and returns big.Int value of this string in wei/atto
@@ -1,13 +1,34 @@
 package web3
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"math/big"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/gochain/gochain/v4/accounts"
 	"github.com/gochain/gochain/v4/accounts/abi"
+	"github.com/gochain/gochain/v4/accounts/keystore"
 	"github.com/gochain/gochain/v4/common"
+	"github.com/gochain/gochain/v4/common/hexutil"
+	"github.com/gochain/gochain/v4/core/state"
+	"github.com/gochain/gochain/v4/core/types"
+	"github.com/gochain/gochain/v4/core/vm/runtime"
+	"github.com/gochain/gochain/v4/crypto"
+	"github.com/gochain/gochain/v4/ethdb"
+	"github.com/gochain/gochain/v4/params"
+	"github.com/gochain/gochain/v4/rlp"
+	"github.com/gochain/gochain/v4/rpc"
+	"github.com/gochain/web3/assets"
 )
 
 func Test_parseParam(t *testing.T) {
@@ -64,7 +85,7 @@ func Test_parseParam(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ConvertArgument(abi.Type{T: tt.t}, tt.param)
+			got, err := ConvertArgument(abi.Type{T: tt.t, Size: tt.s}, tt.param)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("wantErr %v; error = %v", tt.wantErr, err)
 				return
@@ -76,6 +97,115 @@ func Test_parseParam(t *testing.T) {
 	}
 }
 
+func TestConvertArgument_nested(t *testing.T) {
+	const addr1, addr2 = "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"
+	const hash = "0x0123456789012345678901234567890101234567890123456789012345678901"
+	order := []abi.ArgumentMarshaling{
+		{Name: "maker", Type: "address"},
+		{Name: "amounts", Type: "uint256[]"},
+		{Name: "fee", Type: "tuple", Components: []abi.ArgumentMarshaling{{Name: "bps", Type: "uint16"}, {Name: "to", Type: "address"}}},
+	}
+	for _, test := range []struct {
+		name       string
+		typ        string
+		components []abi.ArgumentMarshaling
+		param      interface{}
+	}{
+		{name: "uint256[]", typ: "uint256[]", param: `["1", 2, "0x3"]`},
+		{name: "uint256[] legacy", typ: "uint256[]", param: "[1,2,3]"},
+		{name: "uint256[] go", typ: "uint256[]", param: []int{1, 2, 3}},
+		{name: "uint24[]", typ: "uint24[]", param: "[1,2,3]"},
+		{name: "bytes32[2][]", typ: "bytes32[2][]", param: `[["` + hash + `","` + hash + `"]]`},
+		{name: "address[][]", typ: "address[][]", param: `[["` + addr1 + `"], [], ["` + addr1 + `","` + addr2 + `"]]`},
+		{name: "tuple object", typ: "tuple", components: order,
+			param: `{"maker": "` + addr1 + `", "amounts": [1, 2], "fee": {"bps": 30, "to": "` + addr2 + `"}}`},
+		{name: "tuple array", typ: "tuple", components: order, param: `["` + addr1 + `", [1, 2], [30, "` + addr2 + `"]]`},
+		{name: "tuple[]", typ: "tuple[]", components: order,
+			param: `[{"maker": "` + addr1 + `", "amounts": [], "fee": {"bps": 1, "to": "` + addr2 + `"}}]`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			typ, err := abi.NewType(test.typ, "", test.components)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ConvertArgument(typ, test.param)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			args := abi.Arguments{{Type: typ}}
+			packed, err := args.Pack(got)
+			if err != nil {
+				t.Fatalf("failed to pack %T: %v", got, err)
+			}
+			// Round trip through the encoding.
+			vals, err := args.UnpackValues(packed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(vals[0], got) {
+				t.Errorf("expected %v but got %v", got, vals[0])
+			}
+		})
+	}
+
+	for _, test := range []struct {
+		name  string
+		typ   string
+		param interface{}
+	}{
+		{name: "array length", typ: "uint256[2]", param: "[1,2,3]"},
+		{name: "element", typ: "address[]", param: `["0x1234"]`},
+		{name: "tuple component", typ: "tuple", param: `{"maker": "` + addr1 + `"}`},
+		{name: "tuple json", typ: "tuple", param: `{"maker": `},
+	} {
+		t.Run("invalid "+test.name, func(t *testing.T) {
+			typ, err := abi.NewType(test.typ, "", order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ConvertArgument(typ, test.param); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestNamedOutputs(t *testing.T) {
+	const abiJSON = `[{"type": "function", "name": "get", "inputs": [], "outputs": [
+		{"name": "owner", "type": "address"},
+		{"name": "", "type": "uint256"},
+		{"name": "orders", "type": "tuple[]", "components": [
+			{"name": "id", "type": "bytes32"},
+			{"name": "amounts", "type": "uint256[]"}
+		]}
+	]}]`
+	myabi, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := myabi.Methods["get"].Outputs
+	orders, err := ConvertArgument(outputs[2].Type, `[{"id": "0x0100000000000000000000000000000000000000000000000000000000000000", "amounts": [1, 2]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := outputs.Pack(common.Address{19: 1}, big.NewInt(5), orders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, err := outputs.UnpackValues(packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(NamedOutputs(outputs, convertOutputParams(vals)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const exp = `{"1":5,"orders":[{"amounts":[1,2],"id":"0x0100000000000000000000000000000000000000000000000000000000000000"}],"owner":"0x0000000000000000000000000000000000000001"}`
+	if string(got) != exp {
+		t.Errorf("expected %s but got %s", exp, got)
+	}
+}
+
 func TestParseGwei(t *testing.T) {
 	for _, tt := range []struct {
 		val    string
@@ -107,3 +237,1501 @@ func TestParseGwei(t *testing.T) {
 		})
 	}
 }
+
+// feeService is an in-process eth service for sending transactions, with an optional base fee.
+type feeService struct {
+	baseFee    *big.Int // nil if EIP-1559 is not supported
+	historyErr error    // returned by FeeHistory, if set
+	raw        []byte
+}
+
+// methodNotFoundError is the error for calls of unsupported methods.
+type methodNotFoundError struct{ method string }
+
+func (e *methodNotFoundError) Error() string {
+	return "the method " + e.method + " does not exist/is not available"
+}
+func (e *methodNotFoundError) ErrorCode() int { return -32601 }
+
+func (s *feeService) ChainId() *hexutil.Big { return (*hexutil.Big)(big.NewInt(5)) }
+
+func (s *feeService) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 { return 7 }
+
+func (s *feeService) GasPrice() *hexutil.Big { return (*hexutil.Big)(Gwei(30)) }
+
+func (s *feeService) MaxPriorityFeePerGas() *hexutil.Big { return (*hexutil.Big)(Gwei(2)) }
+
+func (s *feeService) FeeHistory(count hexutil.Uint64, last string, percentiles []float64) (map[string]interface{}, error) {
+	if s.historyErr != nil {
+		return nil, s.historyErr
+	}
+	if s.baseFee == nil {
+		return nil, &methodNotFoundError{method: "eth_feeHistory"}
+	}
+	return map[string]interface{}{
+		"oldestBlock":   "0x1",
+		"baseFeePerGas": []*hexutil.Big{(*hexutil.Big)(s.baseFee), (*hexutil.Big)(s.baseFee)},
+		"gasUsedRatio":  []float64{0.5},
+	}, nil
+}
+
+func (s *feeService) SendRawTransaction(raw hexutil.Bytes) common.Hash {
+	s.raw = raw
+	return crypto.Keccak256Hash(raw)
+}
+
+func TestSendWithOpts(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.Address{1}
+	for _, test := range []struct {
+		name        string
+		baseFee     *big.Int
+		opts        TxOpts
+		dynamic     bool
+		maxFee, tip *big.Int
+		gasPrice    *big.Int
+		historyErr  error
+		err         bool
+	}{
+		{name: "dynamic", baseFee: Gwei(10), dynamic: true, maxFee: Gwei(22), tip: Gwei(2)},
+		{name: "dynamic-tip", baseFee: Gwei(10), opts: TxOpts{MaxPriorityFeePerGas: Gwei(3)}, dynamic: true, maxFee: Gwei(23), tip: Gwei(3)},
+		{name: "dynamic-max", baseFee: Gwei(10), opts: TxOpts{MaxFeePerGas: Gwei(1)}, dynamic: true, maxFee: Gwei(1), tip: Gwei(1)},
+		{name: "legacy-price", baseFee: Gwei(10), opts: TxOpts{GasPrice: Gwei(40)}, gasPrice: Gwei(40)},
+		{name: "legacy-fallback", gasPrice: Gwei(30)},
+		{name: "legacy-fallback-zero-base-fee", baseFee: new(big.Int), gasPrice: Gwei(30)},
+		{name: "legacy-fallback-fees", opts: TxOpts{MaxFeePerGas: Gwei(50)}, err: true},
+		{name: "fee-history-error", baseFee: Gwei(10), historyErr: errors.New("internal error"), err: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			svc := &feeService{baseFee: test.baseFee, historyErr: test.historyErr}
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("eth", svc); err != nil {
+				t.Fatal(err)
+			}
+			c := NewClient(rpc.DialInProc(srv))
+			defer c.Close()
+
+			test.opts.GasLimit = 21000
+			tx, err := SendWithOpts(context.Background(), c, hexutil.Encode(crypto.FromECDSA(key)), to, big.NewInt(1), test.opts)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tx.Hash != crypto.Keccak256Hash(svc.raw) {
+				t.Errorf("expected hash %s but got %s", crypto.Keccak256Hash(svc.raw).Hex(), tx.Hash.Hex())
+			}
+			if !test.dynamic {
+				var ltx types.Transaction
+				if err := rlp.DecodeBytes(svc.raw, &ltx); err != nil {
+					t.Fatalf("failed to decode legacy transaction: %v", err)
+				}
+				if ltx.GasPrice().Cmp(test.gasPrice) != 0 {
+					t.Errorf("expected gas price %s but got %s", test.gasPrice, ltx.GasPrice())
+				}
+				sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(5)), &ltx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sender != from {
+					t.Errorf("expected sender %s but got %s", from.Hex(), sender.Hex())
+				}
+				return
+			}
+			if svc.raw[0] != DynamicFeeTxType {
+				t.Fatalf("expected dynamic fee transaction but got type %d", svc.raw[0])
+			}
+			var dtx DynamicFeeTx
+			if err := rlp.DecodeBytes(svc.raw[1:], &dtx); err != nil {
+				t.Fatalf("failed to decode dynamic fee transaction: %v", err)
+			}
+			if dtx.ChainID.Int64() != 5 || dtx.Nonce != 7 || *dtx.To != to {
+				t.Errorf("unexpected transaction: %+v", dtx)
+			}
+			if dtx.MaxFeePerGas.Cmp(test.maxFee) != 0 {
+				t.Errorf("expected max fee %s but got %s", test.maxFee, dtx.MaxFeePerGas)
+			}
+			if dtx.MaxPriorityFeePerGas.Cmp(test.tip) != 0 {
+				t.Errorf("expected priority fee %s but got %s", test.tip, dtx.MaxPriorityFeePerGas)
+			}
+			sender, err := dtx.Sender()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sender != from {
+				t.Errorf("expected sender %s but got %s", from.Hex(), sender.Hex())
+			}
+		})
+	}
+}
+
+func TestSignerTransactions(t *testing.T) {
+	ctx := context.Background()
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &feeService{}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+
+	// sent returns the last transaction sent, checking that acct signed it.
+	sent := func() *types.Transaction {
+		var tx types.Transaction
+		if err := rlp.DecodeBytes(svc.raw, &tx); err != nil {
+			t.Fatalf("failed to decode transaction: %v", err)
+		}
+		sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(5)), &tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sender != acct.Address() {
+			t.Errorf("expected sender %s but got %s", acct.Address().Hex(), sender.Hex())
+		}
+		return &tx
+	}
+
+	const abiJSON = `[
+		{"type": "constructor", "inputs": [{"name": "n", "type": "uint256"}]},
+		{"type": "function", "name": "set", "inputs": [{"name": "n", "type": "uint256"}], "outputs": []}
+	]`
+	dir := t.TempDir()
+	binFile, abiFile := filepath.Join(dir, "C.bin"), filepath.Join(dir, "C.abi")
+	if err := ioutil.WriteFile(binFile, []byte("0x6001"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(abiFile, []byte(abiJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeployBinWithSigner(ctx, c, acct, binFile, abiFile, TxOpts{GasLimit: 100000}, "3"); err != nil {
+		t.Fatal(err)
+	}
+	tx := sent()
+	if exp := append([]byte{0x60, 0x01}, common.LeftPadBytes([]byte{3}, 32)...); tx.To() != nil || !bytes.Equal(tx.Data(), exp) {
+		t.Errorf("expected deployment with data %x but got %x to %v", exp, tx.Data(), tx.To())
+	}
+
+	myabi, err := GetABI(abiJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.Address{1}
+	if _, err := CallTransactFunctionWithSigner(ctx, c, *myabi, to.Hex(), acct, "set", nil, nil, 100000, "4"); err != nil {
+		t.Fatal(err)
+	}
+	tx = sent()
+	if exp := append(crypto.Keccak256([]byte("set(uint256)"))[:4], common.LeftPadBytes([]byte{4}, 32)...); *tx.To() != to || !bytes.Equal(tx.Data(), exp) {
+		t.Errorf("expected call to %s with data %x but got %x to %v", to.Hex(), exp, tx.Data(), tx.To())
+	}
+}
+
+func TestDecodeRevertReason(t *testing.T) {
+	const abiJSON = `[
+		{"type": "function", "name": "withdraw", "inputs": [{"name": "amount", "type": "uint256"}], "outputs": []},
+		{"type": "error", "name": "InsufficientBalance", "inputs": [{"name": "available", "type": "uint256"}, {"name": "required", "type": "uint256"}]}
+	]`
+	myabi, errs, err := GetABIWithErrors(abiJSON)
+	if err != nil {
+		t.Fatalf("failed to read ABI with custom errors: %v", err)
+	}
+	if _, ok := myabi.Methods["withdraw"]; !ok {
+		t.Error("expected withdraw method")
+	}
+
+	word := func(i int64) []byte { return common.LeftPadBytes(big.NewInt(i).Bytes(), 32) }
+	concat := func(bs ...[]byte) []byte { return bytes.Join(bs, nil) }
+	for _, test := range []struct {
+		name string
+		data []byte
+		exp  string
+	}{
+		{name: "error", data: concat(hexutil.MustDecode("0x08c379a0"), word(32), word(9), common.RightPadBytes([]byte("not owner"), 32)), exp: "not owner"},
+		{name: "panic", data: concat(hexutil.MustDecode("0x4e487b71"), word(0x11)), exp: "panic: arithmetic underflow or overflow (0x11)"},
+		{name: "custom", data: concat(crypto.Keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4], word(1), word(2)), exp: "InsufficientBalance(available: 1, required: 2)"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DecodeRevertReason(test.data, errs...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.exp {
+				t.Errorf("expected %q but got %q", test.exp, got)
+			}
+		})
+	}
+	if _, err := DecodeRevertReason(hexutil.MustDecode("0xdeadbeef"), errs...); err == nil {
+		t.Error("expected error for unknown selector")
+	}
+
+	// Custom errors are only decoded with the ABI which defines them.
+	custom := concat(crypto.Keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4], word(1), word(2))
+	if _, err := DecodeRevertReason(custom); err == nil {
+		t.Error("expected error for custom error without its ABI")
+	}
+	rerr, ok := DecodeRevertError(&RevertError{Data: custom}, errs).(*RevertError)
+	if !ok || rerr.Reason != "InsufficientBalance(available: 1, required: 2)" {
+		t.Errorf("expected decoded custom error but got %v", rerr)
+	}
+}
+
+func TestDecodeCallData(t *testing.T) {
+	const abiJSON = `[
+		{"type": "constructor", "inputs": [{"name": "name", "type": "string"}, {"name": "supply", "type": "uint256"}]},
+		{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}], "outputs": [{"name": "", "type": "bool"}]}
+	]`
+	myabi, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	input, err := myabi.Pack("transfer", to, big.NewInt(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd, err := DecodeCallData(myabi, input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cd.Sig != "transfer(address,uint256)" {
+		t.Errorf("expected signature transfer(address,uint256) but got %s", cd.Sig)
+	}
+	exp := "transfer(address to: 0x0000000000000000000000000000000000000001, uint256 amount: 5)"
+	if got := cd.String(); got != exp {
+		t.Errorf("expected %q but got %q", exp, got)
+	}
+	if _, err := DecodeCallData(myabi, hexutil.MustDecode("0xdeadbeef")); err == nil {
+		t.Error("expected error for unknown selector")
+	}
+
+	args, err := myabi.Pack("", "Token", big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := hexutil.MustDecode("0x6080604052348015600f57600080fd5b50")
+	cd, err = DecodeConstructorArgs(myabi, append(code, args...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp = "constructor(string name: Token, uint256 supply: 1000)"
+	if got := cd.String(); got != exp {
+		t.Errorf("expected %q but got %q", exp, got)
+	}
+}
+
+func TestParseLogs(t *testing.T) {
+	const tokenJSON = `[
+		{"type": "event", "name": "Transfer", "anonymous": false, "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "to", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256", "indexed": false}
+		]},
+		{"type": "event", "name": "Noted", "anonymous": true, "inputs": [
+			{"name": "ok", "type": "bool", "indexed": true},
+			{"name": "note", "type": "string", "indexed": true},
+			{"name": "count", "type": "uint8", "indexed": false}
+		]}
+	]`
+	const otherJSON = `[
+		{"type": "event", "name": "Ping", "anonymous": false, "inputs": [{"name": "n", "type": "uint256", "indexed": true}]}
+	]`
+	token, err := abi.JSON(strings.NewReader(tokenJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := abi.JSON(strings.NewReader(otherJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenAddr, otherAddr := common.Address{19: 0xa}, common.Address{19: 0xb}
+	word := func(i int64) []byte { return common.LeftPadBytes(big.NewInt(i).Bytes(), 32) }
+	from, to := common.Address{19: 1}, common.Address{19: 2}
+	noteHash := crypto.Keccak256Hash([]byte("hello"))
+	logs := []*types.Log{
+		{
+			Address:     tokenAddr,
+			Topics:      []common.Hash{token.Events["Transfer"].ID, from.Hash(), to.Hash()},
+			Data:        word(7),
+			BlockNumber: 16,
+			TxHash:      common.Hash{1},
+			TxIndex:     2,
+			Index:       3,
+		},
+		{
+			Address: otherAddr,
+			Topics:  []common.Hash{other.Events["Ping"].ID, common.BytesToHash(word(9))},
+		},
+		{
+			Address: tokenAddr,
+			Topics:  []common.Hash{common.BytesToHash(word(1)), noteHash},
+			Data:    word(5),
+		},
+		{Address: tokenAddr},
+		// An ERC-721 Transfer, with the same signature but an indexed token ID.
+		{
+			Address: tokenAddr,
+			Topics:  []common.Hash{token.Events["Transfer"].ID, from.Hash(), to.Hash(), common.BytesToHash(word(7))},
+		},
+		// A Transfer without its value.
+		{
+			Address: tokenAddr,
+			Topics:  []common.Hash{token.Events["Transfer"].ID, from.Hash(), to.Hash()},
+			Index:   9,
+		},
+	}
+	bad := logs[len(logs)-1]
+
+	if _, err := ParseLog(token, logs[4]); err != ErrUnknownEvent {
+		t.Errorf("expected unknown event error for ERC-721 transfer but got %v", err)
+	}
+	events, err := ParseLogs(token, logs)
+	if errs, ok := err.(LogErrors); !ok || len(errs) != 1 || errs[0].Log != bad {
+		t.Fatalf("expected error for log %d only but got %v", bad.Index, err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events but got %d: %v", len(events), events)
+	}
+	transfer := events[0]
+	if transfer.Name != "Transfer" || transfer.Fields["from"] != from || transfer.Fields["to"] != to ||
+		transfer.Fields["value"].(*big.Int).Int64() != 7 {
+		t.Errorf("unexpected transfer: %+v", transfer)
+	}
+	if transfer.Address != tokenAddr || transfer.BlockNumber != 16 || transfer.TxHash != (common.Hash{1}) ||
+		transfer.TxIndex != 2 || transfer.LogIndex != 3 {
+		t.Errorf("unexpected transfer log details: %+v", transfer)
+	}
+	noted := events[1]
+	if noted.Name != "Noted" || noted.Fields["ok"] != true || noted.Fields["note"] != noteHash || noted.Fields["count"] != uint8(5) {
+		t.Errorf("unexpected anonymous event: %+v", noted)
+	}
+	if !reflect.DeepEqual(noted.Hashed, []string{"note"}) {
+		t.Errorf("expected hashed note but got %v", noted.Hashed)
+	}
+
+	if _, err := ParseLog(token, logs[1]); err != ErrUnknownEvent {
+		t.Errorf("expected unknown event error but got %v", err)
+	}
+
+	events, err = ParseLogsByAddress(map[common.Address]abi.ABI{tokenAddr: token, otherAddr: other}, logs)
+	if errs, ok := err.(LogErrors); !ok || len(errs) != 1 || errs[0].Log != bad {
+		t.Fatalf("expected error for log %d only but got %v", bad.Index, err)
+	}
+	if len(events) != 3 || events[1].Name != "Ping" || events[1].Fields["n"].(*big.Int).Int64() != 9 {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestGetABI_humanReadable(t *testing.T) {
+	const sigs = `function transfer(address to, uint256 amount) returns (bool)
+		function balanceOf(address owner) external view returns (uint)
+		function submit((uint256 id, bytes data)[] calls) payable
+		event Transfer(address indexed from, address indexed to, uint256 value)
+		error InsufficientBalance(uint256 available, uint256 required)
+		constructor(string name)`
+	inline, err := GetABI("function balanceOf(address owner) view returns (uint256); event Approval(address indexed owner, address indexed spender, uint256 value)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inline.Methods["balanceOf"].IsConstant() || inline.Events["Approval"].Sig != "Approval(address,address,uint256)" {
+		t.Errorf("unexpected inline ABI: %v %v", inline.Methods, inline.Events)
+	}
+
+	myabi, err := readAbi(strings.NewReader(sigs))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, sig := range map[string]string{
+		"transfer":  "transfer(address,uint256)",
+		"balanceOf": "balanceOf(address)",
+		"submit":    "submit((uint256,bytes)[])",
+	} {
+		if m, ok := myabi.Methods[name]; !ok || m.Sig != sig {
+			t.Errorf("expected method %s but got %v", sig, m)
+		}
+	}
+	if m := myabi.Methods["balanceOf"]; !m.IsConstant() || len(m.Outputs) != 1 || m.Outputs[0].Type.String() != "uint256" {
+		t.Errorf("unexpected balanceOf: %v", m)
+	}
+	if !myabi.Methods["submit"].IsPayable() {
+		t.Error("expected submit to be payable")
+	}
+	e := myabi.Events["Transfer"]
+	if !e.Inputs[0].Indexed || !e.Inputs[1].Indexed || e.Inputs[2].Indexed || e.Inputs[2].Name != "value" {
+		t.Errorf("unexpected event inputs: %v", e.Inputs)
+	}
+	if len(myabi.Constructor.Inputs) != 1 {
+		t.Errorf("expected constructor input but got %v", myabi.Constructor.Inputs)
+	}
+	_, errs, err := GetABIWithErrors(sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || errs[0].Sig() != "InsufficientBalance(uint256,uint256)" {
+		t.Errorf("expected custom error InsufficientBalance(uint256,uint256) but got %v", errs)
+	}
+
+	// JSON arrays of signatures are accepted too.
+	if _, err := readAbi(strings.NewReader(`["function hello() view returns (string)"]`)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := readAbi(strings.NewReader("function broken(uint256")); err == nil {
+		t.Error("expected error for invalid signature")
+	}
+}
+
+func TestEncodeCallData(t *testing.T) {
+	data, err := EncodeCallData("transfer(address to, uint256 amount)", "0x0000000000000000000000000000000000000001", "1000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := "0xa9059cbb" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"00000000000000000000000000000000000000000000000000000000000003e8"
+	if got := hexutil.Encode(data); got != exp {
+		t.Errorf("expected %s but got %s", exp, got)
+	}
+	args, err := EncodeCallData("(address,uint256)", "0x0000000000000000000000000000000000000001", 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(args, data[4:]) {
+		t.Errorf("expected arguments %x but got %x", data[4:], args)
+	}
+	if _, err := EncodeCallData("transfer(address,uint256)", "0x01"); err == nil {
+		t.Error("expected error for missing argument")
+	}
+	if human, err := EncodeCallData("function transfer(address to, uint256 amount) external returns (bool)",
+		"0x0000000000000000000000000000000000000001", "1000"); err != nil || !bytes.Equal(human, data) {
+		t.Errorf("expected %x for human-readable signature but got %x: %v", data, human, err)
+	}
+	if _, err := EncodeCallData("transfer from(address,uint256)", "0x01", "1"); err == nil {
+		t.Error("expected error for name with whitespace")
+	}
+
+	for sig, exp := range map[string]string{
+		"transfer(address,uint256)": "transfer(address,uint256)",
+		"function transfer(address to, uint amount) external returns (bool)":      "transfer(address,uint256)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)": "Transfer(address,address,uint256)",
+		"submit((uint256 id, bytes data)[] calls, bool)":                          "submit((uint256,bytes)[],bool)",
+	} {
+		got, err := CanonicalSignature(sig)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", sig, err)
+		} else if got != exp {
+			t.Errorf("expected %s but got %s", exp, got)
+		}
+	}
+}
+
+func TestDiffABI(t *testing.T) {
+	old, oldErrs, err := GetABIWithErrors(`function transfer(address to, uint256 amount) returns (bool)
+function balanceOf(address owner) view returns (uint256)
+function mint(address to, uint256 amount)
+function approve(address spender, uint256 amount) returns (bool)
+function deposit() payable
+event Transfer(address indexed from, address indexed to, uint256 value)
+event Approval(address indexed owner, address indexed spender, uint256 value)
+error Unauthorized()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, newErrs, err := GetABIWithErrors(`function transfer(address to, uint256 amount) returns (bool)
+function balanceOf(address owner) returns (uint256)
+function mint(address to, uint256 amount, bytes data)
+function approve(address spender, uint256 amount) returns (uint256)
+function deposit()
+function burn(uint256 amount)
+event Transfer(address indexed from, address to, uint256 value)
+event Paused()
+error InsufficientBalance(uint256 needed)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := append(DiffABI(*old, *new), DiffErrors(oldErrs, newErrs)...)
+	exp := []string{
+		"BREAKING removed event Approval(address,address,uint256)",
+		"BREAKING changed event Transfer(address,address,uint256): indexed argument positions changed from (0,1) to (0)",
+		"BREAKING changed function approve(address,uint256): outputs changed from (bool) to (uint256)",
+		"BREAKING changed function balanceOf(address): mutability changed from view to nonpayable",
+		"BREAKING changed function deposit(): mutability changed from payable to nonpayable",
+		"BREAKING changed function mint(address,uint256): inputs changed to mint(address,uint256,bytes)",
+		"additive added event Paused()",
+		"additive added function burn(uint256)",
+		"BREAKING removed error Unauthorized()",
+		"additive added error InsufficientBalance(uint256)",
+	}
+	if len(changes) != len(exp) {
+		t.Fatalf("expected %d changes but got %d: %v", len(exp), len(changes), changes)
+	}
+	for i, c := range changes {
+		if got := c.String(); got != exp[i] {
+			t.Errorf("expected %q but got %q", exp[i], got)
+		}
+	}
+	if !BreakingChanges(changes) {
+		t.Error("expected breaking changes")
+	}
+	if changes := DiffABI(*old, *old); len(changes) != 0 {
+		t.Errorf("expected no changes but got %v", changes)
+	}
+}
+
+func TestDiffStorageLayout(t *testing.T) {
+	types := `"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+		"t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"}
+	}`
+	// The old layout is a string, as output by older compilers.
+	old, err := parseStorageLayout(json.RawMessage(strconv.Quote(`{"storage": [
+		{"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+		{"label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+		{"label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+		{"label": "balances", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_uint256)"},
+		{"label": "cap", "offset": 0, "slot": "3", "type": "t_uint256"},
+		{"label": "fee", "offset": 0, "slot": "4", "type": "t_uint256"}
+	], ` + types + `}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		storage string
+		exp     []string
+	}{
+		{
+			name: "compatible",
+			storage: `{"label": "admin", "offset": 0, "slot": "0", "type": "t_address"},
+				{"label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+				{"label": "total", "offset": 0, "slot": "1", "type": "t_uint256"},
+				{"label": "balances", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_uint256)"},
+				{"label": "cap", "offset": 0, "slot": "3", "type": "t_uint256"},
+				{"label": "fee", "offset": 0, "slot": "4", "type": "t_uint256"},
+				{"label": "limit", "offset": 0, "slot": "5", "type": "t_uint256"}`,
+			exp: []string{
+				"additive renamed owner (slot 0, offset 0): renamed to admin",
+				"additive added limit (slot 5, offset 0)",
+			},
+		},
+		{
+			name: "incompatible",
+			storage: `{"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+				{"label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+				{"label": "total", "offset": 0, "slot": "1", "type": "t_uint128"},
+				{"label": "minted", "offset": 0, "slot": "2", "type": "t_uint256"},
+				{"label": "balances", "offset": 0, "slot": "3", "type": "t_mapping(t_address,t_uint256)"},
+				{"label": "fee", "offset": 0, "slot": "4", "type": "t_uint256"}`,
+			exp: []string{
+				"BREAKING retyped total (slot 1, offset 0): type changed from uint256 to uint128",
+				"BREAKING reordered balances (slot 2, offset 0): position now holds minted",
+				"BREAKING reordered cap (slot 3, offset 0): position now holds balances",
+				"BREAKING inserted minted (slot 2, offset 0): overlaps existing storage",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var new StorageLayout
+			if err := json.Unmarshal([]byte(`{"storage": [`+test.storage+`], `+types+`}`), &new); err != nil {
+				t.Fatal(err)
+			}
+			changes := DiffStorageLayout(old, &new)
+			if len(changes) != len(test.exp) {
+				t.Fatalf("expected %d changes but got %d: %v", len(test.exp), len(changes), changes)
+			}
+			for i, c := range changes {
+				if got := c.String(); got != test.exp[i] {
+					t.Errorf("expected %q but got %q", test.exp[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffStorageLayout_gaps(t *testing.T) {
+	// The struct types have different AST ids, as in different compilations.
+	types := `"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_array(t_uint256)2_storage": {"base": "t_uint256", "encoding": "inplace", "label": "uint256[2]", "numberOfBytes": "64"},
+		"t_array(t_uint256)3_storage": {"base": "t_uint256", "encoding": "inplace", "label": "uint256[3]", "numberOfBytes": "96"},
+		"t_array(t_uint256)4_storage": {"base": "t_uint256", "encoding": "inplace", "label": "uint256[4]", "numberOfBytes": "128"},
+		"t_struct(Config)1_storage": {"encoding": "inplace", "label": "struct C.Config", "numberOfBytes": "64", "members": [
+			{"label": "rate", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"label": "owner", "offset": 0, "slot": "1", "type": "t_address"}
+		]},
+		"t_struct(Config)2_storage": {"encoding": "inplace", "label": "struct C.Config", "numberOfBytes": "64", "members": [
+			{"label": "fee", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"label": "admin", "offset": 0, "slot": "1", "type": "t_address"}
+		]},
+		"t_struct(Config)3_storage": {"encoding": "inplace", "label": "struct C.Config", "numberOfBytes": "64", "members": [
+			{"label": "rate", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"label": "owner", "offset": 0, "slot": "1", "type": "t_uint256"}
+		]}
+	}`
+	var old StorageLayout
+	if err := json.Unmarshal([]byte(`{"storage": [
+		{"label": "config", "offset": 0, "slot": "0", "type": "t_struct(Config)1_storage"},
+		{"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)3_storage"}
+	], `+types+`}`), &old); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		storage string
+		exp     []string
+	}{
+		{
+			name: "shrunk",
+			storage: `{"label": "config", "offset": 0, "slot": "0", "type": "t_struct(Config)2_storage"},
+				{"label": "limit", "offset": 0, "slot": "2", "type": "t_uint256"},
+				{"label": "__gap", "offset": 0, "slot": "3", "type": "t_array(t_uint256)2_storage"}`,
+			exp: []string{
+				"additive shrunk __gap (slot 2, offset 0): type changed from uint256[3] to uint256[2] at slot 3",
+				"additive added limit (slot 2, offset 0): in storage reserved by __gap",
+			},
+		},
+		{
+			name: "shrunk-end",
+			storage: `{"label": "config", "offset": 0, "slot": "0", "type": "t_struct(Config)1_storage"},
+				{"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)2_storage"},
+				{"label": "limit", "offset": 0, "slot": "4", "type": "t_uint256"}`,
+			exp: []string{
+				"additive shrunk __gap (slot 2, offset 0): type changed from uint256[3] to uint256[2] at slot 2",
+				"additive added limit (slot 4, offset 0): in storage reserved by __gap",
+			},
+		},
+		{
+			name: "grown",
+			storage: `{"label": "config", "offset": 0, "slot": "0", "type": "t_struct(Config)3_storage"},
+				{"label": "__gap", "offset": 0, "slot": "2", "type": "t_array(t_uint256)4_storage"}`,
+			exp: []string{
+				"BREAKING retyped config (slot 0, offset 0): type changed from struct C.Config{uint256@0:0,address@1:0} to struct C.Config{uint256@0:0,uint256@1:0}",
+				"BREAKING retyped __gap (slot 2, offset 0): type changed from uint256[3] to uint256[4]",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var new StorageLayout
+			if err := json.Unmarshal([]byte(`{"storage": [`+test.storage+`], `+types+`}`), &new); err != nil {
+				t.Fatal(err)
+			}
+			changes := DiffStorageLayout(&old, &new)
+			if len(changes) != len(test.exp) {
+				t.Fatalf("expected %d changes but got %d: %v", len(test.exp), len(changes), changes)
+			}
+			for i, c := range changes {
+				if got := c.String(); got != test.exp[i] {
+					t.Errorf("expected %q but got %q", test.exp[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestGetABI_bundled(t *testing.T) {
+	for name, fns := range map[string][]string{
+		"erc20":   {"transfer", "balanceOf"},
+		"erc721":  {"ownerOf", "transferFrom"},
+		"erc1155": {"balanceOfBatch", "safeTransferFrom", "safeBatchTransferFrom"},
+		"erc4626": {"asset", "previewDeposit", "previewRedeem", "deposit", "redeem", "decimals"},
+		"erc2612": {"permit", "nonces", "DOMAIN_SEPARATOR", "transfer"},
+	} {
+		myabi, err := GetABI(name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		for _, fn := range fns {
+			if _, ok := myabi.Methods[fn]; !ok {
+				t.Errorf("%s: missing function %s", name, fn)
+			}
+		}
+	}
+}
+
+// codeService is an in-process eth service for a contract with code and storage, which supports the
+// interfaces, and whose implementation() and target() functions return target.
+type codeService struct {
+	code       hexutil.Bytes
+	storage    map[common.Hash]common.Hash
+	interfaces map[string]bool
+	target     common.Address
+}
+
+func (s *codeService) GetCode(address common.Address, block string) (hexutil.Bytes, error) {
+	return s.code, nil
+}
+
+func (s *codeService) GetStorageAt(address common.Address, slot common.Hash, block string) (hexutil.Bytes, error) {
+	val := s.storage[slot]
+	return val[:], nil
+}
+
+func (s *codeService) Call(msg map[string]interface{}, block string) (hexutil.Bytes, error) {
+	data := hexutil.MustDecode(msg["data"].(string))
+	switch hexutil.Encode(data) {
+	case "0x5c60da1b", "0xd4b83992", "0x8da5cb5b": // implementation(), target(), owner()
+		if s.target == (common.Address{}) {
+			return nil, &revertError{}
+		}
+		return common.LeftPadBytes(s.target[:], 32), nil
+	}
+	if s.interfaces == nil || !bytes.HasPrefix(data, hexutil.MustDecode("0x01ffc9a7")) {
+		return nil, &revertError{}
+	}
+	var res [32]byte
+	if s.interfaces[hexutil.Encode(data[4:8])] {
+		res[31] = 1
+	}
+	return res[:], nil
+}
+
+func TestDetectStandards(t *testing.T) {
+	var code []byte
+	for _, sig := range []string{"totalSupply()", "balanceOf(address)", "transfer(address,uint256)",
+		"transferFrom(address,address,uint256)", "approve(address,uint256)", "allowance(address,address)"} {
+		code = append(append(code, 0x63), crypto.Keccak256([]byte(sig))[:4]...)
+	}
+	for _, test := range []struct {
+		name       string
+		interfaces map[string]bool
+		exp        []string
+	}{
+		{name: "erc20", exp: []string{"ERC20"}},
+		{name: "erc721", interfaces: map[string]bool{"0x01ffc9a7": true, "0x80ac58cd": true, "0x5b5e139f": true},
+			exp: []string{"ERC20", "ERC165", "ERC721", "ERC721Metadata"}},
+		{name: "invalid erc165", interfaces: map[string]bool{"0x01ffc9a7": true, "0xffffffff": true, "0x80ac58cd": true},
+			exp: []string{"ERC20"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("eth", &codeService{code: code, interfaces: test.interfaces}); err != nil {
+				t.Fatal(err)
+			}
+			c := NewClient(rpc.DialInProc(srv))
+			defer c.Close()
+			got, err := DetectStandards(context.Background(), c, common.Address{1})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("expected %v but got %v", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestParseCodeMetadata(t *testing.T) {
+	code := hexutil.MustDecode("0x6080604052" +
+		"a2646970667358221220" + strings.Repeat("ab", 32) +
+		"64736f6c63430008130033")
+	md, ok := ParseCodeMetadata(code)
+	if !ok {
+		t.Fatal("expected metadata")
+	}
+	exp := CodeMetadata{Compiler: "solc", Version: "0.8.19", IPFS: "1220" + strings.Repeat("ab", 32)}
+	if *md != exp {
+		t.Errorf("expected %+v but got %+v", exp, *md)
+	}
+	if _, ok := ParseCodeMetadata(hexutil.MustDecode("0x6080604052")); ok {
+		t.Error("expected no metadata")
+	}
+
+	target := common.HexToAddress("0xbebebebebebebebebebebebebebebebebebebebe")
+	proxy := hexutil.MustDecode("0x363d3d373d3d3d363d73" + target.Hex()[2:] + "5af43d82803e903d91602b57fd5bf3")
+	if got, ok := MinimalProxyTarget(proxy); !ok || got != target {
+		t.Errorf("expected minimal proxy for %s but got %s", target.Hex(), got.Hex())
+	}
+}
+
+func TestResolveProxy(t *testing.T) {
+	impl, admin, beacon := common.Address{1}, common.Address{2}, common.Address{3}
+	code := hexutil.MustDecode("0x6080604052")
+	targetCode := append(append([]byte{0x63}, crypto.Keccak256([]byte("target()"))[:4]...), code...)
+	minimalProxy := hexutil.MustDecode("0x363d3d373d3d3d363d73" + impl.Hex()[2:] + "5af43d82803e903d91602b57fd5bf3")
+	word := func(a common.Address) common.Hash { return common.BytesToHash(a[:]) }
+	for _, test := range []struct {
+		name string
+		svc  *codeService
+		exp  *Proxy
+	}{
+		{name: "not a proxy", svc: &codeService{code: code}},
+		{name: "eip1167", svc: &codeService{code: minimalProxy}, exp: &Proxy{Kind: ProxyEIP1167, Implementation: impl}},
+		{name: "eip1967", svc: &codeService{code: code, storage: map[common.Hash]common.Hash{
+			EIP1967ImplementationSlot: word(impl), EIP1967AdminSlot: word(admin)}},
+			exp: &Proxy{Kind: ProxyEIP1967, Implementation: impl, Admin: &admin}},
+		{name: "eip1967 beacon", svc: &codeService{code: code, storage: map[common.Hash]common.Hash{
+			EIP1967BeaconSlot: word(beacon)}, target: impl},
+			exp: &Proxy{Kind: ProxyEIP1967Beacon, Implementation: impl, Beacon: &beacon}},
+		{name: "eip1822", svc: &codeService{code: code, storage: map[common.Hash]common.Hash{
+			EIP1822ProxiableSlot: word(impl)}},
+			exp: &Proxy{Kind: ProxyEIP1822, Implementation: impl}},
+		{name: "owner upgradeable", svc: &codeService{code: code, storage: map[common.Hash]common.Hash{
+			OwnerProxyTargetSlot: word(impl)}},
+			exp: &Proxy{Kind: ProxyOwner, Implementation: impl}},
+		{name: "target", svc: &codeService{code: targetCode, target: impl}, exp: &Proxy{Kind: ProxyTarget, Implementation: impl}},
+		{name: "not an address", svc: &codeService{code: code, storage: map[common.Hash]common.Hash{
+			EIP1822ProxiableSlot: common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000001")}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("eth", test.svc); err != nil {
+				t.Fatal(err)
+			}
+			c := NewClient(rpc.DialInProc(srv))
+			defer c.Close()
+			got, err := ResolveProxy(context.Background(), c, common.Address{9})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("expected %+v but got %+v", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestProxyAdminOwner(t *testing.T) {
+	owner := common.Address{4}
+	code := hexutil.MustDecode("0x6080604052")
+	ownerCode := append(append([]byte{0x63}, crypto.Keccak256([]byte("owner()"))[:4]...), code...)
+	for _, test := range []struct {
+		name string
+		svc  *codeService
+		exp  *common.Address
+	}{
+		{name: "account", svc: &codeService{}},
+		{name: "no owner function", svc: &codeService{code: code, target: owner}},
+		{name: "owner reverts", svc: &codeService{code: ownerCode}},
+		{name: "owner", svc: &codeService{code: ownerCode, target: owner}, exp: &owner},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("eth", test.svc); err != nil {
+				t.Fatal(err)
+			}
+			c := NewClient(rpc.DialInProc(srv))
+			defer c.Close()
+			got, err := ProxyAdminOwner(context.Background(), c, common.Address{2})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("expected %v but got %v", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestProxyBytecode(t *testing.T) {
+	admin, user := common.Address{0xad}, common.Address{0x05}
+	// Both implementations store the first calldata word in slot 0 when called with data,
+	// and otherwise return slot 0 (plus one for impl2).
+	impl1, impl2 := common.Address{0x01}, common.Address{0x02}
+	st, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	st.SetCode(impl1, hexutil.MustDecode("0x3615600c57600035600055005b60005460005260206000f3"))
+	st.SetCode(impl2, hexutil.MustDecode("0x3615600c57600035600055005b60005460010160005260206000f3"))
+	chainConfig := *params.TestChainConfig
+	chainConfig.ConstantinopleBlock, chainConfig.PetersburgBlock = new(big.Int), new(big.Int)
+	cfg := func(from common.Address) *runtime.Config {
+		return &runtime.Config{ChainConfig: &chainConfig, State: st, Origin: from, GasLimit: 10000000}
+	}
+	word := func(v int64) []byte { return common.LeftPadBytes(big.NewInt(v).Bytes(), 32) }
+	get := func(proxy, from common.Address) (int64, error) {
+		ret, _, err := runtime.Call(proxy, nil, cfg(from))
+		return new(big.Int).SetBytes(ret).Int64(), err
+	}
+	adminABI, err := GetABI(assets.ProxyAdminABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(to, from common.Address, method string, args ...interface{}) ([]byte, error) {
+		input, err := adminABI.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, _, err := runtime.Call(to, input, cfg(from))
+		return ret, err
+	}
+
+	_, proxy, _, err := runtime.Create(hexutil.MustDecode(assets.TransparentProxyCode(impl1, admin, word(42))), cfg(admin))
+	if err != nil {
+		t.Fatalf("failed to create transparent proxy: %v", err)
+	}
+	if got := st.GetState(proxy, EIP1967ImplementationSlot); got != common.BytesToHash(impl1[:]) {
+		t.Errorf("expected implementation %s but got %s", impl1.Hex(), got.Hex())
+	}
+	proxyAdmin := common.BytesToAddress(st.GetState(proxy, EIP1967AdminSlot).Bytes())
+	if len(st.GetCode(proxyAdmin)) == 0 {
+		t.Fatalf("expected a ProxyAdmin contract in the admin slot but got %s", proxyAdmin.Hex())
+	}
+	if ret, err := call(proxyAdmin, user, "owner"); err != nil || common.BytesToAddress(ret) != admin {
+		t.Errorf("expected ProxyAdmin owner %s but got %x: %v", admin.Hex(), ret, err)
+	}
+	if v, err := get(proxy, user); err != nil || v != 42 {
+		t.Errorf("expected initialized value 42 but got %d: %v", v, err)
+	}
+	if v, err := get(proxy, admin); err != nil || v != 42 {
+		t.Errorf("expected owner call to be delegated but got %d: %v", v, err)
+	}
+	if _, err := get(proxy, proxyAdmin); err == nil {
+		t.Error("expected ProxyAdmin call to be rejected instead of delegated")
+	}
+	if _, err := call(proxyAdmin, user, "upgradeAndCall", proxy, impl2, []byte{}); err == nil {
+		t.Error("expected upgrade by a non-owner to fail")
+	}
+	if _, err := call(proxyAdmin, admin, "upgradeAndCall", proxy, user, []byte{}); err == nil {
+		t.Error("expected upgrade to a non-contract to fail")
+	}
+	if _, err := call(proxyAdmin, admin, "upgradeAndCall", proxy, impl2, []byte{}); err != nil {
+		t.Fatalf("failed to upgrade: %v", err)
+	}
+	if got := st.GetState(proxy, EIP1967ImplementationSlot); got != common.BytesToHash(impl2[:]) {
+		t.Errorf("expected implementation %s but got %s", impl2.Hex(), got.Hex())
+	}
+	if v, err := get(proxy, user); err != nil || v != 43 {
+		t.Errorf("expected upgraded value 43 but got %d: %v", v, err)
+	}
+	if _, err := call(proxyAdmin, admin, "upgradeAndCall", proxy, impl1, word(7)); err != nil {
+		t.Fatalf("failed to upgrade and call: %v", err)
+	}
+	if v, err := get(proxy, user); err != nil || v != 7 {
+		t.Errorf("expected value 7 but got %d: %v", v, err)
+	}
+
+	_, uups, _, err := runtime.Create(hexutil.MustDecode(assets.ERC1967ProxyCode(impl2, word(5))), cfg(admin))
+	if err != nil {
+		t.Fatalf("failed to create ERC1967 proxy: %v", err)
+	}
+	if v, err := get(uups, admin); err != nil || v != 6 {
+		t.Errorf("expected value 6 but got %d: %v", v, err)
+	}
+	if got := st.GetState(uups, EIP1967ImplementationSlot); got != common.BytesToHash(impl2[:]) {
+		t.Errorf("expected implementation %s but got %s", impl2.Hex(), got.Hex())
+	}
+}
+
+func TestTypedData_Hash(t *testing.T) {
+	// The example from EIP-712.
+	td, err := ParseTypedData([]byte(`{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Person": [
+				{"name": "name", "type": "string"},
+				{"name": "wallet", "type": "address"}
+			],
+			"Mail": [
+				{"name": "from", "type": "Person"},
+				{"name": "to", "type": "Person"},
+				{"name": "contents", "type": "string"}
+			]
+		},
+		"primaryType": "Mail",
+		"domain": {
+			"name": "Ether Mail",
+			"version": "1",
+			"chainId": 1,
+			"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+		},
+		"message": {
+			"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+			"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+			"contents": "Hello, Bob!"
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := td.EncodeType("Mail"); err != nil || s != "Mail(Person from,Person to,string contents)Person(string name,address wallet)" {
+		t.Errorf("unexpected encoded type %q: %v", s, err)
+	}
+	if h, err := td.DomainSeparator(); err != nil || h.Hex() != "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f" {
+		t.Errorf("unexpected domain separator %s: %v", h.Hex(), err)
+	}
+	h, err := td.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Hex() != "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Errorf("unexpected hash %s", h.Hex())
+	}
+
+	// The domain type is implied when omitted.
+	delete(td.Types, "EIP712Domain")
+	if got, err := td.Hash(); err != nil || got != h {
+		t.Errorf("expected hash %s without EIP712Domain type but got %s: %v", h.Hex(), got.Hex(), err)
+	}
+
+	cow, err := ParsePrivateKey(crypto.Keccak256Hash([]byte("cow")).Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := cow.SignTypedData(context.Background(), td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const exp = "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c"
+	if hexutil.Encode(sig) != exp {
+		t.Errorf("expected signature %s but got %s", exp, hexutil.Encode(sig))
+	}
+}
+
+// signerService is a remote signer for an account, which calls tamper, if set, before signing.
+type signerService struct {
+	acct   *Account
+	tamper func(*Transaction)
+}
+
+func (s *signerService) SignTransaction(args struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Type                 hexutil.Uint64  `json:"type"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big    `json:"value"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+}) (map[string]interface{}, error) {
+	if args.From != s.acct.Address() {
+		return nil, errors.New("unknown account")
+	}
+	tx := &Transaction{Type: uint64(args.Type), Nonce: uint64(args.Nonce), GasLimit: uint64(args.Gas), To: args.To,
+		Value: args.Value.ToInt(), Input: args.Data, ChainID: args.ChainID.ToInt(),
+		GasPrice: (*big.Int)(args.GasPrice), MaxFeePerGas: (*big.Int)(args.MaxFeePerGas), MaxPriorityFeePerGas: (*big.Int)(args.MaxPriorityFeePerGas)}
+	if s.tamper != nil {
+		s.tamper(tx)
+	}
+	raw, err := s.acct.SignTx(context.Background(), tx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": tx}, nil
+}
+
+func (s *signerService) SignTypedData_v4(from common.Address, data *TypedData) (hexutil.Bytes, error) {
+	return s.acct.SignTypedData(context.Background(), data)
+}
+
+func TestSigners(t *testing.T) {
+	ctx := context.Background()
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if _, err := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(acct.Key(), "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeystoreSigner(dir, acct.Address(), "wrong"); err == nil {
+		t.Error("expected wrong password to fail")
+	}
+	ks, err := NewKeystoreSigner(dir, acct.Address(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ks.Lock()
+
+	svc := &signerService{acct: acct}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	remote := NewRemoteSigner(rpc.DialInProc(srv), acct.Address())
+	defer remote.Close()
+
+	to := common.Address{1}
+	newTxs := func() []*Transaction {
+		return []*Transaction{
+			{ChainID: big.NewInt(5), Nonce: 1, GasLimit: 21000, To: &to, Value: big.NewInt(2), GasPrice: Gwei(30)},
+			{Type: DynamicFeeTxType, ChainID: big.NewInt(5), Nonce: 1, GasLimit: 21000, Value: big.NewInt(0), Input: []byte{1, 2},
+				MaxFeePerGas: Gwei(30), MaxPriorityFeePerGas: Gwei(2)},
+		}
+	}
+	exp := newTxs()
+	var expRaw [][]byte
+	for _, tx := range exp {
+		raw, err := acct.SignTx(ctx, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expRaw = append(expRaw, raw)
+	}
+	for name, signer := range map[string]Signer{"keystore": ks, "remote": remote} {
+		t.Run(name, func(t *testing.T) {
+			if signer.Address() != acct.Address() {
+				t.Errorf("expected address %s but got %s", acct.Address().Hex(), signer.Address().Hex())
+			}
+			for i, tx := range newTxs() {
+				raw, err := signer.SignTx(ctx, tx)
+				if err != nil {
+					t.Fatalf("failed to sign transaction %d: %v", i, err)
+				}
+				if !bytes.Equal(raw, expRaw[i]) {
+					t.Errorf("expected raw transaction %d %x but got %x", i, expRaw[i], raw)
+				}
+				if tx.Hash != exp[i].Hash || tx.From != acct.Address() {
+					t.Errorf("expected transaction %d hash %s from %s but got %s from %s", i, exp[i].Hash.Hex(), acct.Address().Hex(), tx.Hash.Hex(), tx.From.Hex())
+				}
+			}
+		})
+	}
+
+	// The remote signer's transaction must match the request.
+	for name, tamper := range map[string]func(*Transaction){
+		"nonce": func(tx *Transaction) { tx.Nonce++ },
+		"to":    func(tx *Transaction) { tx.To = &common.Address{2} },
+		"value": func(tx *Transaction) { tx.Value = big.NewInt(3) },
+		"fee":   func(tx *Transaction) { tx.GasPrice, tx.MaxFeePerGas = Gwei(31), Gwei(31) },
+	} {
+		svc.tamper = tamper
+		for i, tx := range newTxs() {
+			if _, err := remote.SignTx(ctx, tx); err == nil {
+				t.Errorf("expected error for transaction %d with a different %s", i, name)
+			}
+		}
+	}
+	svc.tamper = nil
+
+	if pub, err := SignerPublicKey(ctx, ks); err != nil || crypto.PubkeyToAddress(*pub) != acct.Address() {
+		t.Errorf("expected public key of %s: %v", acct.Address().Hex(), err)
+	}
+	td := &TypedData{
+		Types:       map[string][]TypedDataField{"Ping": {{Name: "n", Type: "uint256"}}},
+		PrimaryType: "Ping",
+		Domain:      map[string]interface{}{"name": "test", "chainId": "5"},
+		Message:     map[string]interface{}{"n": "1"},
+	}
+	sig, err := acct.SignTypedData(ctx, td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := remote.SignTypedData(ctx, td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sig) {
+		t.Errorf("expected typed data signature %x but got %x", sig, got)
+	}
+}
+
+func TestMnemonic(t *testing.T) {
+	// Test vectors from https://github.com/trezor/python-mnemonic/blob/master/vectors.json.
+	for _, test := range []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	} {
+		t.Run(test.mnemonic, func(t *testing.T) {
+			entropy := common.FromHex(test.entropy)
+			mnemonic, err := EntropyToMnemonic(entropy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mnemonic != test.mnemonic {
+				t.Errorf("expected mnemonic %q but got %q", test.mnemonic, mnemonic)
+			}
+			got, err := MnemonicToEntropy(test.mnemonic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, entropy) {
+				t.Errorf("expected entropy %x but got %x", entropy, got)
+			}
+			seed, err := MnemonicToSeed(test.mnemonic, "TREZOR")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex := common.Bytes2Hex(seed); hex != test.seed {
+				t.Errorf("expected seed %s but got %s", test.seed, hex)
+			}
+			seed, err = MnemonicToSeed(" "+strings.ToUpper(test.mnemonic)+"\n", "TREZOR")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex := common.Bytes2Hex(seed); hex != test.seed {
+				t.Errorf("expected upper case seed %s but got %s", test.seed, hex)
+			}
+		})
+	}
+
+	for _, bad := range []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abut",
+	} {
+		if err := ValidateMnemonic(bad); err == nil {
+			t.Errorf("expected invalid mnemonic %q", bad)
+		}
+	}
+
+	mnemonic, err := NewMnemonic(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Fields(mnemonic)); n != 24 {
+		t.Errorf("expected 24 words but got %d", n)
+	}
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		t.Errorf("expected valid mnemonic %q: %v", mnemonic, err)
+	}
+	if _, err := NewMnemonic(13); err == nil {
+		t.Error("expected error for 13 words")
+	}
+}
+
+func TestDeriveAccount(t *testing.T) {
+	// Test vector 1 from BIP-32.
+	seed := common.FromHex("000102030405060708090a0b0c0d0e0f")
+	for path, key := range map[string]string{
+		"m":                      "0xe8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":                   "0xedb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1":                 "0x3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		"m/0'/1/2'/2/1000000000": "0x471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+	} {
+		var p accounts.DerivationPath
+		if path != "m" {
+			var err error
+			p, err = ParseHDPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		acct, err := DeriveAccount(seed, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acct.PrivateKey() != key {
+			t.Errorf("expected %s key %s but got %s", path, key, acct.PrivateKey())
+		}
+	}
+
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	acct, err := MnemonicAccount(mnemonic, "", DefaultHDPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"; acct.PublicKey() != exp {
+		t.Errorf("expected address %s but got %s", exp, acct.PublicKey())
+	}
+	if _, err := ParseHDPath("0/1"); err == nil {
+		t.Error("expected error for relative path")
+	}
+}
+
+// nonceService is a feeService with a settable pending nonce, which rejects sends with errs in turn.
+type nonceService struct {
+	feeService
+	pending uint64
+	errs    []string
+	nonces  []uint64 // of the sent transactions
+}
+
+func (s *nonceService) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	return hexutil.Uint64(s.pending)
+}
+
+func (s *nonceService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return common.Hash{}, errors.New(err)
+	}
+	var ltx types.Transaction
+	if err := rlp.DecodeBytes(raw, &ltx); err != nil {
+		return common.Hash{}, err
+	}
+	s.nonces = append(s.nonces, ltx.Nonce())
+	s.raw = raw
+	return crypto.Keccak256Hash(raw), nil
+}
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+	svc := &nonceService{pending: 7}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewNonceManager(c, acct.Address())
+	var wg sync.WaitGroup
+	got := make([]uint64, 20)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			n, err := m.Next(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+			got[i] = n
+		}(i)
+	}
+	wg.Wait()
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	for i, n := range got {
+		if n != uint64(7+i) {
+			t.Fatalf("expected nonces 7 to 26 but got %v", got)
+		}
+	}
+
+	// Released nonces are handed out again, lowest first, and trailing ones shrink the sequence.
+	m.Release(10)
+	m.Release(26)
+	m.Release(25)
+	for _, exp := range []uint64{10, 25, 26} {
+		if n, err := m.Next(ctx); err != nil || n != exp {
+			t.Errorf("expected nonce %d but got %d: %v", exp, n, err)
+		}
+	}
+
+	// Syncing skips past nonces used elsewhere.
+	m.Release(12)
+	svc.pending = 30
+	if err := m.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Next(ctx); err != nil || n != 30 {
+		t.Errorf("expected nonce 30 but got %d: %v", n, err)
+	}
+
+	// Sends re-sign with a fresh nonce after a conflict, and release the nonce after other failures.
+	opts := TxOpts{GasLimit: 21000, GasPrice: Gwei(1), Nonces: m}
+	svc.pending = 35
+	svc.errs = []string{"nonce too low", "insufficient funds for gas * price + value"}
+	if _, err := SendWithSigner(ctx, c, acct, common.Address{1}, big.NewInt(1), opts); err == nil {
+		t.Fatal("expected insufficient funds error")
+	}
+	if _, err := SendWithSigner(ctx, c, acct, common.Address{1}, big.NewInt(1), opts); err != nil {
+		t.Fatal(err)
+	}
+	// A known transaction was already sent, so its nonce is used up.
+	svc.errs = []string{"already known"}
+	if tx, err := SendWithSigner(ctx, c, acct, common.Address{1}, big.NewInt(1), opts); err != nil || tx.Nonce != 36 {
+		t.Fatalf("expected known transaction with nonce 36: %v", err)
+	}
+	svc.errs = []string{"replacement transaction underpriced"}
+	if _, err := SendWithSigner(ctx, c, acct, common.Address{1}, big.NewInt(1), opts); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []uint64{35, 38}; !reflect.DeepEqual(svc.nonces, exp) {
+		t.Errorf("expected sent nonces %v but got %v", exp, svc.nonces)
+	}
+
+	// Without a nonce manager, sends use the pending nonce and aren't retried.
+	svc.pending, svc.nonces = 40, nil
+	svc.errs = []string{"nonce too low"}
+	if _, err := SendWithSigner(ctx, c, acct, common.Address{1}, big.NewInt(1), TxOpts{GasLimit: 21000, GasPrice: Gwei(1)}); err == nil {
+		t.Fatal("expected nonce too low error")
+	}
+	if tx, err := SendWithSigner(ctx, c, acct, common.Address{1}, big.NewInt(1), TxOpts{GasLimit: 21000, GasPrice: Gwei(1)}); err != nil || tx.Nonce != 40 {
+		t.Fatalf("expected transaction with pending nonce 40: %v", err)
+	}
+
+	other, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SendWithSigner(ctx, c, other, common.Address{1}, big.NewInt(1), opts); err == nil {
+		t.Error("expected error for a nonce manager of another account")
+	}
+}
+
+func TestNewTransaction_offline(t *testing.T) {
+	ctx := context.Background()
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(5)
+	to := common.Address{1}
+	nonce := uint64(9)
+	for _, opts := range []TxOpts{
+		{Nonce: &nonce, GasLimit: 21000, GasPrice: Gwei(2)},
+		{Nonce: &nonce, GasLimit: 21000, MaxFeePerGas: Gwei(3), MaxPriorityFeePerGas: Gwei(1)},
+	} {
+		tx, err := NewTransaction(chainID, &to, big.NewInt(1), []byte{1, 2}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := acct.SignTx(ctx, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeRawTransaction(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.From != acct.Address() || got.Hash != tx.Hash || got.Nonce != nonce || got.Type != tx.Type ||
+			got.ChainID.Cmp(chainID) != 0 || *got.To != to || !bytes.Equal(got.Input, []byte{1, 2}) {
+			t.Errorf("expected decoded transaction %+v but got %+v", tx, got)
+		}
+		if tx.Type == DynamicFeeTxType && (got.MaxFeePerGas.Cmp(Gwei(3)) != 0 || got.MaxPriorityFeePerGas.Cmp(Gwei(1)) != 0) {
+			t.Errorf("expected fees 3 and 1 gwei but got %s and %s", got.MaxFeePerGas, got.MaxPriorityFeePerGas)
+		}
+		if tx.Type == 0 && got.GasPrice.Cmp(Gwei(2)) != 0 {
+			t.Errorf("expected gas price 2 gwei but got %s", got.GasPrice)
+		}
+	}
+
+	for name, opts := range map[string]TxOpts{
+		"nonce":        {GasLimit: 21000, GasPrice: Gwei(2)},
+		"gas limit":    {Nonce: &nonce, GasPrice: Gwei(2)},
+		"fees":         {Nonce: &nonce, GasLimit: 21000},
+		"priority fee": {Nonce: &nonce, GasLimit: 21000, MaxFeePerGas: Gwei(3)},
+		"both":         {Nonce: &nonce, GasLimit: 21000, GasPrice: Gwei(2), MaxFeePerGas: Gwei(3)},
+	} {
+		if _, err := NewTransaction(chainID, &to, nil, nil, opts); err == nil {
+			t.Errorf("expected error for missing or conflicting %s", name)
+		}
+	}
+	if _, err := NewTransaction(nil, &to, nil, nil, TxOpts{Nonce: &nonce, GasLimit: 21000, GasPrice: Gwei(2)}); err == nil {
+		t.Error("expected error for missing chain ID")
+	}
+	if _, err := DecodeRawTransaction([]byte{5, 1}); err == nil {
+		t.Error("expected error for unsupported transaction type")
+	}
+}